@@ -0,0 +1,110 @@
+package elw
+
+import (
+	"context"
+	"time"
+
+	"github.com/gadavy/elw/internal"
+)
+
+// ECS field names of documents written by logger adapters.
+const (
+	TimestampKey    = "@timestamp"
+	LevelKey        = "log.level"
+	LoggerKey       = "log.logger"
+	MessageKey      = "message"
+	StacktraceKey   = "error.stack_trace"
+	ErrorMessageKey = "error.message"
+	ErrorTypeKey    = "error.type"
+	FileNameKey     = "log.origin.file.name"
+	FileLineKey     = "log.origin.file.line"
+	FunctionKey     = "log.origin.function"
+)
+
+// SyncWriter is a writer of logger adapters, implemented by ElasticWriter.
+// Adapters may route entries of some levels to separate writers,
+// e.g. to ones with another IndexName for errors.
+type SyncWriter interface {
+	Write(p []byte) (n int, err error)
+	SyncContext(ctx context.Context) error
+}
+
+// SyncWriters syncs every writer once, even if it's passed several times,
+// e.g. the same writer for several levels, and returns the first error.
+func SyncWriters(ctx context.Context, writers ...SyncWriter) error {
+	var err error
+
+	synced := make(map[SyncWriter]bool, len(writers))
+
+	for _, w := range writers {
+		if synced[w] {
+			continue
+		}
+
+		synced[w] = true
+
+		if e := w.SyncContext(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// LevelWriters routes documents of logger adapters to writers by level and syncs them.
+// Levels are converted to int by adapters.
+type LevelWriters struct {
+	writer      SyncWriter
+	levels      map[int]SyncWriter
+	writers     []SyncWriter
+	syncTimeout time.Duration
+}
+
+// NewLevelWriters returns writers routing all levels to the writer.
+// Sync waits only for the syncTimeout, unlimited if zero.
+func NewLevelWriters(writer SyncWriter, syncTimeout time.Duration) *LevelWriters {
+	return &LevelWriters{
+		writer:      writer,
+		levels:      make(map[int]SyncWriter),
+		writers:     []SyncWriter{writer},
+		syncTimeout: syncTimeout,
+	}
+}
+
+// Set routes documents of the level to the writer, it must not be called after writing.
+func (lw *LevelWriters) Set(level int, writer SyncWriter) {
+	lw.levels[level] = writer
+	lw.writers = append(lw.writers, writer)
+}
+
+// Writer returns the writer of the level.
+func (lw *LevelWriters) Writer(level int) SyncWriter {
+	if w, ok := lw.levels[level]; ok {
+		return w
+	}
+
+	return lw.writer
+}
+
+// Sync is like SyncContext, but waits only for the sync timeout.
+func (lw *LevelWriters) Sync() error {
+	if lw.syncTimeout <= 0 {
+		return lw.SyncContext(context.Background())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lw.syncTimeout)
+	defer cancel()
+
+	return lw.SyncContext(ctx)
+}
+
+// SyncContext syncs every writer, see SyncWriters.
+func (lw *LevelWriters) SyncContext(ctx context.Context) error {
+	return SyncWriters(ctx, lw.writers...)
+}
+
+// AppendQuoted appends s to dst as a JSON string, so adapters encode documents
+// without depending on the internal package.
+func AppendQuoted(dst []byte, s string) []byte {
+	return internal.AppendQuoted(dst, s)
+}
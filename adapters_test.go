@@ -0,0 +1,64 @@
+package elw
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type syncCounter struct {
+	syncs int
+	err   error
+}
+
+func (w *syncCounter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w *syncCounter) SyncContext(context.Context) error {
+	w.syncs++
+
+	return w.err
+}
+
+func TestSyncWriters(t *testing.T) {
+	first, second := errors.New("first"), errors.New("second")
+
+	writer := &syncCounter{}
+	failed := &syncCounter{err: first}
+	other := &syncCounter{err: second}
+
+	err := SyncWriters(context.Background(), writer, failed, writer, other, failed)
+
+	assert.Equal(t, first, err)
+	assert.Equal(t, 1, writer.syncs)
+	assert.Equal(t, 1, failed.syncs)
+	assert.Equal(t, 1, other.syncs)
+
+	assert.NoError(t, SyncWriters(context.Background()))
+}
+
+func TestAppendQuoted(t *testing.T) {
+	assert.Equal(t, `x"a\"b\n\u003c"`, string(AppendQuoted([]byte("x"), "a\"b\n<")))
+}
+
+func TestLevelWriters(t *testing.T) {
+	writer, errWriter := &syncCounter{}, &syncCounter{}
+
+	lw := NewLevelWriters(writer, time.Second)
+	lw.Set(2, errWriter)
+	lw.Set(3, errWriter)
+
+	assert.Equal(t, writer, lw.Writer(1))
+	assert.Equal(t, errWriter, lw.Writer(2))
+	assert.Equal(t, errWriter, lw.Writer(3))
+
+	assert.NoError(t, lw.Sync())
+	assert.NoError(t, NewLevelWriters(writer, 0).Sync())
+
+	assert.Equal(t, 2, writer.syncs)
+	assert.Equal(t, 1, errWriter.syncs)
+}
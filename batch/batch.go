@@ -3,15 +3,41 @@ package batch
 import (
 	"bytes"
 	"time"
+
+	"github.com/gadavy/elw/internal"
+)
+
+const (
+	ActionIndex  = "index"
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
 )
 
 const (
-	firstPartOfMetadata = "{\"index\":{\"_type\":\"doc\",\"_index\":\""
-	lastPartOfMetadata  = "\"}}\n"
-	delimiter           = "-"
-	newline             = "\n"
+	lastPartOfMetadata = "}}\n"
+	delimiter          = "-"
+	newline            = "\n"
+	deleteActionPrefix = "{\"delete\""
 )
 
+// Meta is a metadata of the bulk action.
+type Meta struct {
+	// Action is index if empty.
+	Action string
+	Index  []byte
+	// Type is a mapping type, required by Elasticsearch 6.x and rejected since 8.x.
+	Type string
+	// ID of the document, generated by Elasticsearch if empty.
+	ID []byte
+	// Pipeline is an ingest pipeline of the document, overrides pipeline of the request.
+	Pipeline string
+	// Routing is a custom shard routing value.
+	Routing string
+	// RequireAlias requires Index to be an alias.
+	RequireAlias bool
+}
+
 type Batch struct {
 	buf []byte
 }
@@ -28,14 +54,61 @@ func (b *Batch) AppendBytes(e []byte) {
 	}
 }
 
+// AppendMeta appends metadata of the index action into the daily index.
 func (b *Batch) AppendMeta(indexName, timeFormat string) {
-	b.buf = append(b.buf, firstPartOfMetadata...)
-	b.buf = append(b.buf, indexName...)
-	b.buf = append(b.buf, delimiter...)
-	b.buf = append(b.buf, time.Now().Format(timeFormat)...)
+	index := time.Now().AppendFormat([]byte(indexName+delimiter), timeFormat)
+
+	b.appendMetaStart(ActionIndex, "", nil)
+	b.buf = internal.AppendQuotedBytes(b.buf, index)
+	b.buf = append(b.buf, lastPartOfMetadata...)
+}
+
+// AppendAction appends metadata of the bulk action.
+func (b *Batch) AppendAction(m Meta) {
+	if m.Action == "" {
+		m.Action = ActionIndex
+	}
+
+	b.appendMetaStart(m.Action, m.Type, m.ID)
+	b.buf = internal.AppendQuotedBytes(b.buf, m.Index)
+
+	if m.Pipeline != "" {
+		b.buf = append(b.buf, ",\"pipeline\":"...)
+		b.buf = internal.AppendQuoted(b.buf, m.Pipeline)
+	}
+
+	if m.Routing != "" {
+		b.buf = append(b.buf, ",\"routing\":"...)
+		b.buf = internal.AppendQuoted(b.buf, m.Routing)
+	}
+
+	if m.RequireAlias {
+		b.buf = append(b.buf, ",\"require_alias\":true"...)
+	}
+
 	b.buf = append(b.buf, lastPartOfMetadata...)
 }
 
+func (b *Batch) appendMetaStart(action, docType string, id []byte) {
+	b.buf = append(b.buf, "{\""...)
+	b.buf = append(b.buf, action...)
+	b.buf = append(b.buf, "\":{"...)
+
+	if docType != "" {
+		b.buf = append(b.buf, "\"_type\":\""...)
+		b.buf = append(b.buf, docType...)
+		b.buf = append(b.buf, "\","...)
+	}
+
+	if len(id) > 0 {
+		b.buf = append(b.buf, "\"_id\":"...)
+		b.buf = internal.AppendQuotedBytes(b.buf, id)
+		b.buf = append(b.buf, ',')
+	}
+
+	b.buf = append(b.buf, "\"_index\":"...)
+}
+
 func (b *Batch) Bytes() []byte {
 	return b.buf[0:]
 }
@@ -51,3 +124,66 @@ func (b *Batch) Len() int {
 func (b *Batch) Reset() {
 	b.buf = b.buf[:0]
 }
+
+// Item is a single action of the bulk request body.
+type Item struct {
+	Meta   []byte
+	Source []byte
+}
+
+// Index returns target index of the action.
+func (i Item) Index() string {
+	return i.metaString("_index")
+}
+
+// ID returns ID of the document, empty if it's generated by Elasticsearch.
+func (i Item) ID() string {
+	return i.metaString("_id")
+}
+
+func (i Item) metaString(key string) string {
+	if len(i.Meta) == 0 {
+		return ""
+	}
+
+	if j := bytes.IndexByte(i.Meta[1:], '{'); j >= 0 {
+		value, _ := internal.LookupString(i.Meta[j+1:], key)
+
+		return value
+	}
+
+	return ""
+}
+
+// Items splits bulk request body into actions. Delete actions have no source.
+func Items(body []byte) []Item {
+	var (
+		items []Item
+		line  []byte
+	)
+
+	for len(body) > 0 {
+		line, body = nextLine(body)
+		if len(line) == 0 {
+			continue
+		}
+
+		item := Item{Meta: line}
+
+		if !bytes.HasPrefix(line, []byte(deleteActionPrefix)) {
+			item.Source, body = nextLine(body)
+		}
+
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func nextLine(body []byte) (line, rest []byte) {
+	if i := bytes.IndexByte(body, '\n'); i >= 0 {
+		return body[:i], body[i+1:]
+	}
+
+	return body, nil
+}
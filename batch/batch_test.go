@@ -28,7 +28,7 @@ func TestBatch(t *testing.T) {
 		indexName := "test-index"
 		timeFormat := "2006.01.02"
 
-		expected := []byte(fmt.Sprintf("{\"index\":{\"_type\":\"doc\",\"_index\":\"%s-%s\"}}\n",
+		expected := []byte(fmt.Sprintf("{\"index\":{\"_index\":\"%s-%s\"}}\n",
 			indexName, time.Now().Format(timeFormat)))
 
 		batch.Reset()
@@ -38,6 +38,86 @@ func TestBatch(t *testing.T) {
 		assert.Equal(t, len(expected), batch.Len())
 		assert.Equal(t, string(expected), batch.String())
 	})
+
+	t.Run("AppendAction", func(t *testing.T) {
+		expected := []byte("{\"index\":{\"_index\":\"logs-app\"}}\n")
+
+		batch.Reset()
+		batch.AppendAction(Meta{Index: []byte("logs-app")})
+
+		assert.Equal(t, expected, batch.Bytes())
+	})
+
+	t.Run("AppendActionWithType", func(t *testing.T) {
+		expected := []byte("{\"index\":{\"_type\":\"doc\",\"_index\":\"logs-app\"}}\n")
+
+		batch.Reset()
+		batch.AppendAction(Meta{Action: ActionIndex, Index: []byte("logs-app"), Type: "doc"})
+
+		assert.Equal(t, expected, batch.Bytes())
+	})
+
+	t.Run("AppendActionWithID", func(t *testing.T) {
+		expected := []byte("{\"create\":{\"_id\":\"abc\",\"_index\":\"logs-app\"}}\n")
+
+		batch.Reset()
+		batch.AppendAction(Meta{Action: ActionCreate, Index: []byte("logs-app"), ID: []byte("abc")})
+
+		assert.Equal(t, expected, batch.Bytes())
+	})
+
+	t.Run("AppendActionEscaped", func(t *testing.T) {
+		expected := []byte(`{"delete":{"_id":"a\"b\\c","_index":"users\"x"}}` + "\n")
+
+		batch.Reset()
+		batch.AppendAction(Meta{Action: ActionDelete, Index: []byte(`users"x`), ID: []byte(`a"b\c`)})
+
+		assert.Equal(t, expected, batch.Bytes())
+		assert.Equal(t, `users"x`, Items(batch.Bytes())[0].Index())
+		assert.Equal(t, `a"b\c`, Items(batch.Bytes())[0].ID())
+	})
+
+	t.Run("AppendActionWithParams", func(t *testing.T) {
+		expected := []byte("{\"index\":{\"_index\":\"logs-app\",\"pipeline\":\"geo\\\"ip\"," +
+			"\"routing\":\"user-1\",\"require_alias\":true}}\n")
+
+		batch.Reset()
+		batch.AppendAction(Meta{Index: []byte("logs-app"), Pipeline: "geo\"ip", Routing: "user-1", RequireAlias: true})
+
+		assert.Equal(t, expected, batch.Bytes())
+		assert.Equal(t, "logs-app", Items(batch.Bytes())[0].Index())
+	})
+}
+
+func TestItems(t *testing.T) {
+	body := []byte("{\"index\":{}}\n{\"a\":1}\n{\"delete\":{\"_id\":\"1\"}}\n\n{\"create\":{}}\n{\"b\":2}")
+
+	expected := []Item{
+		{Meta: []byte("{\"index\":{}}"), Source: []byte("{\"a\":1}")},
+		{Meta: []byte("{\"delete\":{\"_id\":\"1\"}}")},
+		{Meta: []byte("{\"create\":{}}"), Source: []byte("{\"b\":2}")},
+	}
+
+	assert.Equal(t, expected, Items(body))
+}
+
+func TestItem_Index(t *testing.T) {
+	tests := []struct {
+		meta     string
+		expected string
+		id       string
+	}{
+		{meta: `{"index":{"_type":"doc","_index":"logs"}}`, expected: "logs"},
+		{meta: `{"create":{"_id":"abc","_index":"logs-app-default"}}`, expected: "logs-app-default", id: "abc"},
+		{meta: `{"index":{}}`, expected: ""},
+		{meta: ``, expected: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.meta, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Item{Meta: []byte(tt.meta)}.Index())
+			assert.Equal(t, tt.id, Item{Meta: []byte(tt.meta)}.ID())
+		})
+	}
 }
 
 func BenchmarkBatch_AppendBytes(b *testing.B) {
@@ -9,10 +9,14 @@ import (
 
 const (
 	// Default writer settings
-	DefaultBatchSize    = 1024 * 1024
-	DefaultRotatePeriod = time.Second
-	DefaultIndexName    = "test-index"
-	DefaultTimeFormat   = "2006.01.02"
+	DefaultBatchSize     = 1024 * 1024
+	DefaultRotatePeriod  = time.Second
+	DefaultIndexName     = "test-index"
+	DefaultTimeFormat    = "2006.01.02"
+	DefaultWorkers       = 2
+	DefaultQueueSize     = 16
+	DefaultTruncateField = "message"
+	DefaultLogRateLimit  = 10 * time.Second
 
 	// Default transport settings
 	DefaultPingInterval   = time.Second
@@ -34,6 +38,95 @@ type Config struct {
 	IndexName    string
 	TimeFormat   string
 
+	// Workers is a number of goroutines sending batches to Elasticsearch.
+	Workers int
+	// QueueSize is a number of full batches waiting for a free worker.
+	QueueSize int
+	// OverflowPolicy defines what to do with a full batch when the queue is full,
+	// OverflowSpill by default.
+	OverflowPolicy OverflowPolicy
+
+	// IndexTemplate overrides IndexName with index name resolved per document
+	// from its top-level fields, e.g. logs-{service}-{level|info}-{date}.
+	// Missing fields are replaced with fallback after the pipe or with "unknown",
+	// {date} is replaced with the document date in TimeFormat. Resolved names are
+	// lowercased and invalid characters are replaced with underscore.
+	IndexTemplate string
+
+	// TimestampFields are top-level document fields with RFC3339 or epoch time,
+	// used instead of the write time to choose a daily index, e.g. @timestamp.
+	// The write time is used when none of them is present or parsable.
+	TimestampFields []string
+	// TimeZone of the index date, local if nil.
+	TimeZone *time.Location
+
+	// DataStream overrides IndexName and IndexTemplate with the data stream name,
+	// e.g. logs-app-default. Documents are written with create action and
+	// @timestamp field is added to documents without it.
+	DataStream string
+	// CreateDataStreamTemplate puts index template of the data stream on start.
+	// If the cluster is unreachable or fails, the error is logged and the template
+	// is put again on reconnect and rotation until accepted.
+	CreateDataStreamTemplate bool
+
+	// StaticFields are added to every JSON object document, e.g. service and environment.
+	// HostFields, KubernetesFields and MergeFields help to fill them.
+	StaticFields map[string]interface{}
+	// OverwriteStaticFields replaces fields of documents with static ones,
+	// otherwise fields already present in documents are kept.
+	OverwriteStaticFields bool
+
+	// DocumentIDs enables IDs of documents generated by the writer,
+	// so retried batches don't produce duplicates. Disabled by default.
+	DocumentIDs IDStrategy
+	// InstanceID is a prefix of IDSequence IDs, random by default.
+	InstanceID string
+
+	// Encoder encodes documents of WriteDocument and WriteFields, JSONEncoder by default.
+	Encoder Encoder
+
+	// Pipeline is an ingest pipeline of documents, Routing is a shard routing value
+	// and RequireAlias requires target index to be an alias. Empty values are omitted.
+	Pipeline     string
+	Routing      string
+	RequireAlias bool
+	// PipelineField and RoutingField are top-level document fields overriding
+	// Pipeline and Routing per document. Fields are kept in documents.
+	PipelineField string
+	RoutingField  string
+
+	// DeadLetterIndex enables indexing of rejected documents into a separate index,
+	// so nothing is lost when a service changes a field type. Disabled if empty.
+	DeadLetterIndex string
+
+	// OnReject is called for documents rejected by Elasticsearch with non-retriable
+	// error, e.g. mapping conflict. Documents rejected with 429 or 503 status
+	// are put to the storage and sent again later.
+	OnReject RejectHandler
+
+	// Logger receives diagnostics of the writer and nodes,
+	// LeveledLogger methods are used if implemented.
+	Logger Logger
+	// LogRateLimit is a minimal interval between messages of the same kind,
+	// DefaultLogRateLimit by default.
+	LogRateLimit time.Duration
+
+	// Hooks receives events of batches and nodes, e.g. to alert when logs are dropped.
+	Hooks Hooks
+
+	// InvalidDocumentPolicy enables validation of documents, so a document which
+	// is not valid JSON can't fail the whole batch. Disabled by default.
+	InvalidDocumentPolicy InvalidDocumentPolicy
+
+	// MaxDocumentSize limits size of a document, BatchSize by default.
+	MaxDocumentSize int
+	// OversizePolicy defines what to do with documents larger than MaxDocumentSize,
+	// OversizeSend by default. Batches rejected with 413 status are split and sent again.
+	OversizePolicy OversizePolicy
+	// TruncateField is a top-level string field shortened by OversizeTruncate policy,
+	// "message" by default.
+	TruncateField string
+
 	// Transport settings
 	NodeURIs       []string
 	RequestTimeout time.Duration
@@ -41,6 +134,16 @@ type Config struct {
 	SuccessCodes   []int
 	UserAgent      string
 
+	// ServerVersion disables detection of the server version, e.g. for OpenSearch
+	// clusters which hide it: transport.Version{Distribution: transport.OpenSearch, Major: 2}.
+	ServerVersion transport.Version
+	// CompatibilityMode enables compatibility headers for Elasticsearch 8.x.
+	CompatibilityMode bool
+	// OnBulk is called after each bulk request, e.g. to export its latency.
+	OnBulk transport.BulkObserver
+	// BulkParams are query parameters of bulk requests, e.g. refresh and wait_for_active_shards.
+	BulkParams transport.BulkParams
+
 	// Storage settings
 	Filepath    string
 	DropStorage bool
@@ -60,6 +163,26 @@ func (c *Config) validate() {
 		c.TimeFormat = DefaultTimeFormat
 	}
 
+	if c.Workers <= 0 {
+		c.Workers = DefaultWorkers
+	}
+
+	if c.QueueSize <= 0 {
+		c.QueueSize = DefaultQueueSize
+	}
+
+	if c.MaxDocumentSize <= 0 || c.MaxDocumentSize > c.BatchSize {
+		c.MaxDocumentSize = c.BatchSize
+	}
+
+	if c.TruncateField == "" {
+		c.TruncateField = DefaultTruncateField
+	}
+
+	if c.LogRateLimit <= 0 {
+		c.LogRateLimit = DefaultLogRateLimit
+	}
+
 	// Check transport settings
 	if c.RotatePeriod <= 0 {
 		c.RotatePeriod = DefaultRotatePeriod
@@ -98,5 +221,11 @@ func (c *Config) getTransportConfig() transport.Config {
 		PingInterval:   c.PingInterval,
 		SuccessCodes:   c.SuccessCodes,
 		UserAgent:      c.UserAgent,
+
+		Version:           c.ServerVersion,
+		CompatibilityMode: c.CompatibilityMode,
+		OnBulk:            c.OnBulk,
+		Hooks:             c.Hooks,
+		BulkParams:        c.BulkParams,
 	}
 }
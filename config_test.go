@@ -11,15 +11,20 @@ import (
 func TestConfig(t *testing.T) {
 	t.Run("Default values", func(t *testing.T) {
 		expected := Config{
-			BatchSize:      MinimalBatchSize,
-			RotatePeriod:   DefaultRotatePeriod,
-			IndexName:      DefaultIndexName,
-			TimeFormat:     DefaultTimeFormat,
-			RequestTimeout: DefaultRequestTimeout,
-			PingInterval:   DefaultPingInterval,
-			SuccessCodes:   []int{200, 201, 202},
-			UserAgent:      DefaultUserAgent,
-			Filepath:       DefaultFilepath,
+			BatchSize:       MinimalBatchSize,
+			RotatePeriod:    DefaultRotatePeriod,
+			IndexName:       DefaultIndexName,
+			TimeFormat:      DefaultTimeFormat,
+			Workers:         DefaultWorkers,
+			QueueSize:       DefaultQueueSize,
+			MaxDocumentSize: MinimalBatchSize,
+			TruncateField:   DefaultTruncateField,
+			LogRateLimit:    DefaultLogRateLimit,
+			RequestTimeout:  DefaultRequestTimeout,
+			PingInterval:    DefaultPingInterval,
+			SuccessCodes:    []int{200, 201, 202},
+			UserAgent:       DefaultUserAgent,
+			Filepath:        DefaultFilepath,
 		}
 
 		config := Config{}
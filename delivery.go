@@ -0,0 +1,231 @@
+package elw
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DeliveryError is returned when batches weren't delivered before the context is done.
+type DeliveryError struct {
+	// Spilled is a number of queued batches put to the storage.
+	Spilled int
+	// InFlight is a number of batches still being sent.
+	InFlight int
+	// StorageUsed reports whether the storage has undelivered batches.
+	StorageUsed bool
+
+	Err error
+}
+
+func (e *DeliveryError) Error() string {
+	parts := make([]string, 0, 3)
+
+	if e.Spilled > 0 {
+		parts = append(parts, fmt.Sprintf("%d batches spilled to storage", e.Spilled))
+	}
+
+	if e.InFlight > 0 {
+		parts = append(parts, fmt.Sprintf("%d batches in flight", e.InFlight))
+	}
+
+	if e.StorageUsed {
+		parts = append(parts, "storage is not empty")
+	}
+
+	if len(parts) == 0 {
+		return fmt.Sprintf("delivery not confirmed: %v", e.Err)
+	}
+
+	return fmt.Sprintf("delivery not confirmed: %s: %v", strings.Join(parts, ", "), e.Err)
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// maxSyncErrors limits number of errors kept by SyncError.
+const maxSyncErrors = 10
+
+// SyncError is returned when batches were neither delivered nor stored.
+type SyncError struct {
+	// Failed is a number of failed batches.
+	Failed int
+	// Errors contains first errors of failed batches.
+	Errors []error
+}
+
+func (e *SyncError) Error() string {
+	errs := make([]string, 0, len(e.Errors))
+
+	for _, err := range e.Errors {
+		errs = append(errs, err.Error())
+	}
+
+	return fmt.Sprintf("%d batches neither delivered nor stored: %s", e.Failed, strings.Join(errs, "; "))
+}
+
+// deliveryGroup tracks delivery of batches rotated between two syncs.
+type deliveryGroup struct {
+	mu      sync.Mutex
+	pending int
+	sealed  bool
+	err     *SyncError
+
+	// done is closed when all batches of the group are released,
+	// allDone is closed when batches of all previous groups are released too.
+	done    chan struct{}
+	allDone chan struct{}
+}
+
+func newDeliveryGroup() *deliveryGroup {
+	return &deliveryGroup{
+		done:    make(chan struct{}),
+		allDone: make(chan struct{}),
+	}
+}
+
+// firstDeliveryGroup returns a group without previous ones.
+func firstDeliveryGroup() *deliveryGroup {
+	g := newDeliveryGroup()
+
+	go func() {
+		<-g.done
+		close(g.allDone)
+	}()
+
+	return g
+}
+
+func (g *deliveryGroup) add() {
+	if g == nil {
+		return
+	}
+
+	g.mu.Lock()
+	g.pending++
+	g.mu.Unlock()
+}
+
+func (g *deliveryGroup) finish(err error) {
+	if g == nil {
+		return
+	}
+
+	g.mu.Lock()
+
+	if err != nil {
+		if g.err == nil {
+			g.err = &SyncError{}
+		}
+
+		if g.err.Failed++; len(g.err.Errors) < maxSyncErrors {
+			g.err.Errors = append(g.err.Errors, err)
+		}
+	}
+
+	if g.pending--; g.pending == 0 && g.sealed {
+		close(g.done)
+	}
+
+	g.mu.Unlock()
+}
+
+// seal forbids adding batches to the group and returns the next group.
+func (g *deliveryGroup) seal() *deliveryGroup {
+	next := newDeliveryGroup()
+
+	g.mu.Lock()
+
+	if g.sealed = true; g.pending == 0 {
+		close(g.done)
+	}
+
+	g.mu.Unlock()
+
+	go func() {
+		<-g.allDone
+		<-next.done
+		close(next.allDone)
+	}()
+
+	return next
+}
+
+// wait blocks until batches of the sealed group and all previous groups are released.
+func (g *deliveryGroup) wait(ctx context.Context) error {
+	select {
+	case <-g.allDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// failure returns error of the group batches that were neither delivered nor stored.
+func (g *deliveryGroup) failure() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.err == nil {
+		return nil
+	}
+
+	return g.err
+}
+
+// waitDelivery waits for the delivery group and spills undelivered
+// queued batches to the storage if the context is done.
+func (w *ElasticWriter) waitDelivery(ctx context.Context, g *deliveryGroup) error {
+	if err := g.wait(ctx); err != nil {
+		return w.undelivered(err)
+	}
+
+	return g.failure()
+}
+
+// waitAll waits for release of all batches and the storage.
+func (w *ElasticWriter) waitAll(ctx context.Context) error {
+	done := make(chan struct{})
+
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return w.undelivered(ctx.Err())
+	}
+}
+
+func (w *ElasticWriter) undelivered(err error) error {
+	spilled := w.spillQueue()
+
+	return &DeliveryError{
+		Spilled:     spilled,
+		InFlight:    int(atomic.LoadInt64(&w.counters.inFlight)),
+		StorageUsed: w.storage.IsUsed(),
+		Err:         err,
+	}
+}
+
+// spillQueue puts all queued batches to the storage.
+func (w *ElasticWriter) spillQueue() (n int) {
+	for {
+		select {
+		case qb := <-w.queue:
+			atomic.AddUint64(&w.counters.spilled, 1)
+
+			w.spillBatch(qb)
+
+			n++
+		default:
+			return n
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package elw
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gadavy/elw/batch"
+	"github.com/gadavy/elw/internal"
+	"github.com/gadavy/elw/test"
+)
+
+func TestDeliveryError_Error(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      *DeliveryError
+		expected string
+	}{
+		{
+			name:     "Empty",
+			err:      &DeliveryError{Err: context.Canceled},
+			expected: "delivery not confirmed: context canceled",
+		},
+		{
+			name:     "Full",
+			err:      &DeliveryError{Spilled: 1, InFlight: 2, StorageUsed: true, Err: context.DeadlineExceeded},
+			expected: "delivery not confirmed: 1 batches spilled to storage, 2 batches in flight, storage is not empty: context deadline exceeded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, tt.err, tt.expected)
+			assert.Equal(t, tt.err.Err, errors.Unwrap(tt.err))
+		})
+	}
+}
+
+func TestSyncError_Error(t *testing.T) {
+	err := &SyncError{Failed: 3, Errors: []error{errors.New("first"), errors.New("second")}}
+
+	assert.EqualError(t, err, "3 batches neither delivered nor stored: first; second")
+}
+
+func TestDeliveryGroup_failure(t *testing.T) {
+	g := firstDeliveryGroup()
+
+	for i := 0; i < maxSyncErrors+2; i++ {
+		g.add()
+		g.finish(errBatchDropped)
+	}
+
+	g.add()
+	g.finish(nil)
+	g.seal()
+
+	assert.Nil(t, g.wait(context.Background()))
+
+	err, ok := g.failure().(*SyncError)
+
+	assert.True(t, ok)
+	assert.Equal(t, maxSyncErrors+2, err.Failed)
+	assert.Len(t, err.Errors, maxSyncErrors)
+}
+
+func TestDeliveryGroup(t *testing.T) {
+	first := firstDeliveryGroup()
+	first.add()
+
+	second := first.seal()
+	second.add()
+
+	third := second.seal()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// second group waits for the first one.
+	second.finish(nil)
+	assert.Equal(t, context.DeadlineExceeded, second.wait(ctx))
+
+	first.finish(nil)
+	assert.Nil(t, second.wait(context.Background()))
+
+	// third group is not sealed yet.
+	third.seal()
+	assert.Nil(t, third.wait(context.Background()))
+}
+
+func TestElasticWriter_SyncContext(t *testing.T) {
+	storage := &test.MockStorage{}
+	storage.On("Put", []byte("{\"index\":{\"_index\":\"-\"}}\nmessage\n")).Return((error)(nil))
+	storage.On("IsUsed").Return(true)
+
+	writer := ElasticWriter{
+		batchSize: 100,
+		transport: &test.MockTransport{},
+		storage:   storage,
+		mu:        internal.NewMutex(),
+		wg:        new(sync.WaitGroup),
+		queue:     make(chan queuedBatch, 1),
+
+		rotatePeriod: time.Second,
+		timer:        time.NewTimer(time.Second),
+	}
+
+	writer.batch = writer.acquireBatch()
+
+	_, _ = writer.Write([]byte("message"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// there are no senders, so queued batch is spilled to the storage.
+	err := writer.SyncContext(ctx)
+
+	assert.Equal(t, &DeliveryError{Spilled: 1, StorageUsed: true, Err: context.DeadlineExceeded}, err)
+	assert.Equal(t, QueueStats{Queued: 1, Spilled: 1}, writer.QueueStats())
+
+	// spilled batch is considered as delivered.
+	assert.Nil(t, writer.SyncContext(context.Background()))
+
+	storage.AssertExpectations(t)
+}
+
+func TestElasticWriter_Sync_failure(t *testing.T) {
+	tr := &test.MockTransport{}
+	tr.On("IsConnected").Return(true)
+	tr.On("SendBulk", []byte("{\"index\":{\"_index\":\"-\"}}\nmessage\n")).Return(errors.New("transport error"))
+
+	storage := &test.MockStorage{}
+	storage.On("Put", []byte("{\"index\":{\"_index\":\"-\"}}\nmessage\n")).Return(errors.New("storage error"))
+
+	writer := ElasticWriter{
+		batchSize: 100,
+		transport: tr,
+		storage:   storage,
+		mu:        internal.NewMutex(),
+		wg:        new(sync.WaitGroup),
+
+		rotatePeriod: time.Second,
+		timer:        time.NewTimer(time.Second),
+	}
+
+	writer.batch = writer.acquireBatch()
+
+	_, _ = writer.Write([]byte("message"))
+
+	assert.EqualError(t, writer.Sync(), "1 batches neither delivered nor stored: storage error")
+
+	// the next sync doesn't report errors of previous batches.
+	assert.Nil(t, writer.Sync())
+}
+
+func TestElasticWriter_WriteContext(t *testing.T) {
+	writer := ElasticWriter{
+		batchSize: 100,
+		transport: &test.MockTransport{},
+		mu:        internal.NewMutex(),
+		wg:        new(sync.WaitGroup),
+
+		rotatePeriod: time.Second,
+		timer:        time.NewTimer(time.Second),
+	}
+
+	writer.batch = writer.acquireBatch()
+
+	writer.mu.Lock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	n, err := writer.WriteContext(ctx, []byte("message"))
+
+	assert.Equal(t, 0, n)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	writer.mu.Unlock()
+
+	n, err = writer.WriteContext(context.Background(), []byte("message"))
+
+	assert.Equal(t, 7, n)
+	assert.Nil(t, err)
+}
+
+func TestElasticWriter_CloseContext(t *testing.T) {
+	tr := &test.MockTransport{}
+	tr.On("IsConnected").Return(false)
+
+	storage := &test.MockStorage{}
+	storage.On("IsUsed").Return(true)
+
+	writer := ElasticWriter{
+		batchSize:   100,
+		transport:   tr,
+		storage:     storage,
+		dropStorage: true,
+		mu:          internal.NewMutex(),
+		wg:          new(sync.WaitGroup),
+		done:        make(internal.Signal, 1),
+
+		rotatePeriod: time.Second,
+		timer:        time.NewTimer(time.Second),
+	}
+
+	writer.batch = writer.acquireBatch()
+
+	// keep the writer busy with the batch in flight.
+	writer.wg.Add(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := writer.CloseContext(ctx)
+
+	// storage is not dropped, because it keeps undelivered batches.
+	assert.Equal(t, &DeliveryError{StorageUsed: true, Err: context.DeadlineExceeded}, err)
+
+	storage.AssertNotCalled(t, "Drop")
+
+	writer.wg.Done()
+}
+
+func TestElasticWriter_CloseContext_locked(t *testing.T) {
+	storage := &test.MockStorage{}
+	storage.On("Put", []byte("message\n")).Return((error)(nil))
+	storage.On("IsUsed").Return(true)
+
+	writer := ElasticWriter{
+		transport: &test.StubTransport{},
+		storage:   storage,
+		mu:        internal.NewMutex(),
+		wg:        new(sync.WaitGroup),
+		done:      make(internal.Signal, 1),
+		queue:     make(chan queuedBatch, 1),
+	}
+
+	b := batch.NewBatch(0)
+	b.AppendBytes([]byte("message"))
+
+	writer.enqueueBatch(context.Background(), b, nil, nil)
+
+	// a write blocked by the full queue holds the writer.
+	writer.mu.Lock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := writer.CloseContext(ctx)
+
+	// queued batch is put to the storage anyway.
+	assert.Equal(t, &DeliveryError{Spilled: 1, StorageUsed: true, Err: context.DeadlineExceeded}, err)
+
+	storage.AssertExpectations(t)
+	writer.mu.Unlock()
+}
@@ -0,0 +1,183 @@
+package elw
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gadavy/elw/batch"
+	"github.com/gadavy/elw/internal"
+	"github.com/gadavy/elw/transport"
+)
+
+const timestampField = "@timestamp"
+
+// Delays between attempts to put index template of the data stream.
+const (
+	minTemplateBackoff = time.Second
+	maxTemplateBackoff = 5 * time.Minute
+)
+
+// appendDocument appends metadata and the document to the current batch.
+// Meta overrides settings of the document if not nil.
+func (w *ElasticWriter) appendDocument(doc []byte, meta *DocumentMeta) {
+	t := w.documentTime(doc)
+
+	if len(w.dataStream) > 0 {
+		w.appendDataStreamDocument(doc, t, meta)
+
+		return
+	}
+
+	switch {
+	case meta != nil && meta.Index != "":
+		w.indexBuf = append(w.indexBuf[:0], meta.Index...)
+	case w.indexTemplate == nil:
+		w.indexBuf = append(w.indexBuf[:0], w.indexName...)
+		w.indexBuf = append(w.indexBuf, '-')
+		w.indexBuf = t.AppendFormat(w.indexBuf, w.timeFormat)
+	default:
+		w.indexBuf = w.indexTemplate.resolve(w.indexBuf[:0], doc, t, w.timeFormat, w.indexName)
+	}
+
+	(*w.batch).AppendAction(w.actionMeta(doc, meta, batch.Meta{
+		Index: w.indexBuf,
+		Type:  w.transport.Version().DocType(),
+	}))
+	(*w.batch).AppendBytes(doc)
+}
+
+// actionMeta fills ID, pipeline, routing and require_alias of the action.
+// Values of meta override values of the document fields, which override the writer defaults.
+func (w *ElasticWriter) actionMeta(doc []byte, meta *DocumentMeta, m batch.Meta) batch.Meta {
+	m.Pipeline, m.Routing, m.RequireAlias = w.pipeline, w.routing, w.requireAlias
+
+	if w.pipelineField != "" {
+		if value, ok := internal.LookupString(doc, w.pipelineField); ok {
+			m.Pipeline = value
+		}
+	}
+
+	if w.routingField != "" {
+		if value, ok := internal.LookupString(doc, w.routingField); ok {
+			m.Routing = value
+		}
+	}
+
+	if meta == nil {
+		m.ID = w.documentID(doc)
+
+		return m
+	}
+
+	if meta.ID != "" {
+		w.idBuf = append(w.idBuf[:0], meta.ID...)
+		m.ID = w.idBuf
+	} else {
+		m.ID = w.documentID(doc)
+	}
+
+	if meta.Pipeline != "" {
+		m.Pipeline = meta.Pipeline
+	}
+
+	if meta.Routing != "" {
+		m.Routing = meta.Routing
+	}
+
+	return m
+}
+
+// compactDocument returns the document without newlines, which separate actions of the bulk request.
+func (w *ElasticWriter) compactDocument(doc []byte) []byte {
+	if bytes.IndexByte(doc, '\n') < 0 {
+		return doc
+	}
+
+	buf := bytes.NewBuffer(w.compactBuf[:0])
+
+	if err := json.Compact(buf, doc); err != nil {
+		// malformed JSON can't contain newlines inside strings, so they are replaced safely.
+		buf.Reset()
+
+		for _, c := range doc {
+			if c == '\n' || c == '\r' {
+				c = ' '
+			}
+
+			buf.WriteByte(c)
+		}
+	}
+
+	w.compactBuf = buf.Bytes()
+
+	return w.compactBuf
+}
+
+// appendDataStreamDocument appends the document to the data stream,
+// which requires create action and @timestamp field.
+func (w *ElasticWriter) appendDataStreamDocument(doc []byte, t time.Time, meta *DocumentMeta) {
+	if _, ok := internal.LookupField(doc, timestampField); !ok {
+		w.timeBuf = append(w.timeBuf[:0], '"')
+		w.timeBuf = t.AppendFormat(w.timeBuf, time.RFC3339Nano)
+		w.timeBuf = append(w.timeBuf, '"')
+
+		w.docBuf = internal.InsertField(w.docBuf[:0], doc, timestampField, w.timeBuf)
+		doc = w.docBuf
+	}
+
+	index := w.dataStream
+
+	if meta != nil && meta.Index != "" {
+		w.indexBuf = append(w.indexBuf[:0], meta.Index...)
+		index = w.indexBuf
+	}
+
+	(*w.batch).AppendAction(w.actionMeta(doc, meta, batch.Meta{
+		Action: batch.ActionCreate,
+		Index:  index,
+	}))
+	(*w.batch).AppendBytes(doc)
+}
+
+// putDataStreamTemplate puts index template of the data stream unless it's already put.
+// Failures are logged and the template is put again by the worker with backoff,
+// template refused by the cluster, e.g. because of missing privileges, is not put again.
+// It's called without the writer lock, so writes don't wait for the request.
+func (w *ElasticWriter) putDataStreamTemplate() {
+	if w.dataStreamTemplate == nil || time.Now().Before(w.templateRetry) || !w.transport.IsConnected() {
+		return
+	}
+
+	err := w.transport.PutIndexTemplate(string(w.dataStream), w.dataStreamTemplate)
+	if err == nil {
+		w.dataStreamTemplate = nil
+
+		return
+	}
+
+	w.setLastError(err)
+
+	if statusErr, ok := err.(*transport.StatusError); ok && !statusErr.IsRetriable() {
+		w.dataStreamTemplate = nil
+		w.logf(levelError, "put index template of data stream %s refused: %v", w.dataStream, err)
+
+		return
+	}
+
+	if w.templateBackoff *= 2; w.templateBackoff < minTemplateBackoff {
+		w.templateBackoff = minTemplateBackoff
+	} else if w.templateBackoff > maxTemplateBackoff {
+		w.templateBackoff = maxTemplateBackoff
+	}
+
+	w.templateRetry = time.Now().Add(w.templateBackoff)
+	w.logf(levelError, "put index template of data stream %s failed, retry in %s: %v", w.dataStream, w.templateBackoff, err)
+}
+
+// dataStreamTemplate returns index template enabling the data stream.
+// Priority is higher than priority of built-in logs-*-* template.
+func dataStreamTemplate(name string) []byte {
+	return []byte(fmt.Sprintf(`{"index_patterns":[%q],"data_stream":{},"priority":200}`, name))
+}
@@ -0,0 +1,219 @@
+package elw
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gadavy/elw/internal"
+	"github.com/gadavy/elw/test"
+	"github.com/gadavy/elw/transport"
+)
+
+func TestElasticWriter_appendDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		writer   *ElasticWriter
+		doc      string
+		expected string
+	}{
+		{
+			name: "IndexName",
+			writer: &ElasticWriter{
+				indexName:       "logs",
+				timeFormat:      DefaultTimeFormat,
+				timestampFields: []string{"time"},
+			},
+			doc:      `{"time":"2020-01-02T03:04:05Z"}`,
+			expected: "{\"index\":{\"_index\":\"logs-2020.01.02\"}}\n{\"time\":\"2020-01-02T03:04:05Z\"}\n",
+		},
+		{
+			name: "IndexTemplate",
+			writer: &ElasticWriter{
+				indexTemplate:   parseIndexTemplate("logs-{level}-{date}"),
+				timeFormat:      DefaultTimeFormat,
+				timestampFields: []string{"time"},
+			},
+			doc:      `{"time":"2020-01-02T03:04:05Z","level":"info"}`,
+			expected: "{\"index\":{\"_index\":\"logs-info-2020.01.02\"}}\n{\"time\":\"2020-01-02T03:04:05Z\",\"level\":\"info\"}\n",
+		},
+		{
+			name: "SequenceID",
+			writer: &ElasticWriter{
+				indexName:       "logs",
+				timeFormat:      DefaultTimeFormat,
+				timestampFields: []string{"time"},
+				idStrategy:      IDSequence,
+				instanceID:      "instance",
+			},
+			doc:      `{"time":"2020-01-02T03:04:05Z"}`,
+			expected: "{\"index\":{\"_id\":\"instance-1\",\"_index\":\"logs-2020.01.02\"}}\n{\"time\":\"2020-01-02T03:04:05Z\"}\n",
+		},
+		{
+			name: "PipelineAndRouting",
+			writer: &ElasticWriter{
+				indexName:       "logs",
+				timeFormat:      DefaultTimeFormat,
+				timestampFields: []string{"time"},
+				pipeline:        "default",
+				routing:         "default",
+				requireAlias:    true,
+				pipelineField:   "pipeline",
+				routingField:    "tenant",
+			},
+			doc: `{"time":"2020-01-02T03:04:05Z","tenant":"acme"}`,
+			expected: "{\"index\":{\"_index\":\"logs-2020.01.02\",\"pipeline\":\"default\",\"routing\":\"acme\",\"require_alias\":true}}\n" +
+				"{\"time\":\"2020-01-02T03:04:05Z\",\"tenant\":\"acme\"}\n",
+		},
+		{
+			name: "DataStream",
+			writer: &ElasticWriter{
+				dataStream:      []byte("logs-app-default"),
+				timestampFields: []string{"time"},
+				location:        time.UTC,
+			},
+			doc:      `{"time":"2020-01-02T03:04:05Z"}`,
+			expected: "{\"create\":{\"_index\":\"logs-app-default\"}}\n{\"@timestamp\":\"2020-01-02T03:04:05Z\",\"time\":\"2020-01-02T03:04:05Z\"}\n",
+		},
+		{
+			name: "DataStreamWithTimestamp",
+			writer: &ElasticWriter{
+				dataStream: []byte("logs-app-default"),
+			},
+			doc:      `{"@timestamp":"2020-01-02T03:04:05Z"}`,
+			expected: "{\"create\":{\"_index\":\"logs-app-default\"}}\n{\"@timestamp\":\"2020-01-02T03:04:05Z\"}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := tt.writer
+			writer.transport = &test.MockTransport{}
+			writer.batch = writer.acquireBatch()
+
+			writer.appendDocument([]byte(tt.doc), nil)
+
+			assert.Equal(t, tt.expected, (*writer.batch).String())
+		})
+	}
+}
+
+func TestElasticWriter_WriteDocuments(t *testing.T) {
+	meta := "{\"index\":{\"_index\":\"logs-2020.01.02\"}}\n"
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "NewlineDelimited",
+			input:    "{\"time\":\"2020-01-02T03:04:05Z\",\"n\":1}\n\n{\"time\":\"2020-01-02T03:04:05Z\",\"n\":2}\n",
+			expected: meta + "{\"time\":\"2020-01-02T03:04:05Z\",\"n\":1}\n" + meta + "{\"time\":\"2020-01-02T03:04:05Z\",\"n\":2}\n",
+		},
+		{
+			name:     "PrettyPrinted",
+			input:    "{\n  \"time\": \"2020-01-02T03:04:05Z\",\n  \"n\": [1, 2]\n}\n",
+			expected: meta + "{\"time\":\"2020-01-02T03:04:05Z\",\"n\":[1,2]}\n",
+		},
+		{
+			name:     "MalformedMultiline",
+			input:    "{\"time\": \"2020-01-02T03:04:05Z\",\n \"n\": x}",
+			expected: meta + "{\"time\": \"2020-01-02T03:04:05Z\",  \"n\": x}\n",
+		},
+		{
+			name:     "Blank",
+			input:    "\n \n",
+			expected: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := &ElasticWriter{
+				batchSize:       1024,
+				indexName:       "logs",
+				timeFormat:      DefaultTimeFormat,
+				timestampFields: []string{"time"},
+				transport:       &test.MockTransport{},
+				mu:              internal.NewMutex(),
+			}
+			writer.batch = writer.acquireBatch()
+
+			n, err := writer.Write([]byte(tt.input))
+
+			assert.NoError(t, err)
+			assert.Equal(t, len(tt.input), n)
+			assert.Equal(t, tt.expected, (*writer.batch).String())
+		})
+	}
+}
+
+func TestDataStreamTemplate(t *testing.T) {
+	expected := `{"index_patterns":["logs-app-default"],"data_stream":{},"priority":200}`
+
+	assert.Equal(t, expected, string(dataStreamTemplate("logs-app-default")))
+}
+
+func TestElasticWriter_putDataStreamTemplate(t *testing.T) {
+	template := dataStreamTemplate("logs-app-default")
+	putErr := errors.New("connection refused")
+
+	transport := &test.MockTransport{}
+	transport.On("IsConnected").Return(false, true, true, true)
+	transport.On("PutIndexTemplate", "logs-app-default", template).Return(putErr).Once()
+	transport.On("PutIndexTemplate", "logs-app-default", template).Return(nil).Once()
+
+	writer := &ElasticWriter{
+		transport:          transport,
+		storage:            &test.StubStorage{},
+		dataStream:         []byte("logs-app-default"),
+		dataStreamTemplate: template,
+	}
+
+	// unreachable cluster.
+	writer.putDataStreamTemplate()
+	assert.NotNil(t, writer.dataStreamTemplate)
+
+	// failed request is kept for the next attempt after backoff.
+	writer.putDataStreamTemplate()
+	assert.NotNil(t, writer.dataStreamTemplate)
+	assert.Equal(t, putErr, writer.Stats().LastError)
+	assert.Equal(t, minTemplateBackoff, writer.templateBackoff)
+
+	writer.putDataStreamTemplate()
+	assert.NotNil(t, writer.dataStreamTemplate)
+
+	writer.templateRetry = time.Time{}
+	writer.putDataStreamTemplate()
+	assert.Nil(t, writer.dataStreamTemplate)
+
+	// template is put once.
+	writer.putDataStreamTemplate()
+	transport.AssertNumberOfCalls(t, "PutIndexTemplate", 2)
+}
+
+func TestElasticWriter_putDataStreamTemplate_refused(t *testing.T) {
+	template := dataStreamTemplate("logs-app-default")
+	putErr := &transport.StatusError{Request: "put index template logs-app-default", StatusCode: http.StatusForbidden}
+
+	tr := &test.MockTransport{}
+	tr.On("IsConnected").Return(true, true)
+	tr.On("PutIndexTemplate", "logs-app-default", template).Return(putErr).Once()
+
+	writer := &ElasticWriter{
+		transport:          tr,
+		storage:            &test.StubStorage{},
+		dataStream:         []byte("logs-app-default"),
+		dataStreamTemplate: template,
+	}
+
+	// refused template is not put again.
+	writer.putDataStreamTemplate()
+	assert.Nil(t, writer.dataStreamTemplate)
+	assert.Equal(t, putErr, writer.Stats().LastError)
+
+	writer.putDataStreamTemplate()
+	tr.AssertNumberOfCalls(t, "PutIndexTemplate", 1)
+}
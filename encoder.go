@@ -0,0 +1,94 @@
+package elw
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+)
+
+// Encoder encodes documents of WriteDocument and WriteFields.
+type Encoder interface {
+	// Encode appends JSON encoding of v to dst.
+	Encode(dst []byte, v interface{}) ([]byte, error)
+}
+
+// JSONEncoder is the default Encoder using encoding/json without HTML escaping.
+type JSONEncoder struct{}
+
+func (JSONEncoder) Encode(dst []byte, v interface{}) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(v); err != nil {
+		return dst, err
+	}
+
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// DocumentMeta overrides writer settings for a single document, empty values are ignored.
+type DocumentMeta struct {
+	// Index overrides IndexName, IndexTemplate and DataStream.
+	Index string
+	// ID overrides ID generated according to DocumentIDs.
+	ID string
+	// Pipeline and Routing override Config values and document fields.
+	Pipeline string
+	Routing  string
+}
+
+// validate returns ErrInvalidIndex or ErrInvalidID if Elasticsearch refuses Index or ID.
+func (m DocumentMeta) validate() error {
+	if m.Index != "" {
+		if err := validateIndexName(m.Index); err != nil {
+			return err
+		}
+	}
+
+	return validateDocumentID(m.ID)
+}
+
+// WriteDocument encodes v with the Encoder and appends it to the current batch.
+func (w *ElasticWriter) WriteDocument(v interface{}) error {
+	return w.WriteDocumentContext(context.Background(), v, DocumentMeta{})
+}
+
+// WriteFields encodes fields as a JSON object and appends it to the current batch.
+func (w *ElasticWriter) WriteFields(fields map[string]interface{}) error {
+	return w.WriteDocumentContext(context.Background(), fields, DocumentMeta{})
+}
+
+// WriteDocumentContext is like WriteDocument with settings of the document overridden by meta,
+// waits for the writer only until the context is done. ErrInvalidIndex and ErrInvalidID
+// are returned for Index and ID of meta refused by Elasticsearch.
+func (w *ElasticWriter) WriteDocumentContext(ctx context.Context, v interface{}, meta DocumentMeta) error {
+	if err := meta.validate(); err != nil {
+		return err
+	}
+
+	bp, ok := w.encodePool.Get().(*[]byte)
+	if !ok {
+		bp = new([]byte)
+	}
+
+	defer w.encodePool.Put(bp)
+
+	doc, err := w.encoder.Encode((*bp)[:0], v)
+	if err != nil {
+		return err
+	}
+
+	*bp = doc
+
+	if err = w.mu.LockContext(ctx); err != nil {
+		return err
+	}
+
+	w.addDocument(ctx, w.compactDocument(doc), &meta)
+
+	w.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package elw
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gadavy/elw/internal"
+	"github.com/gadavy/elw/test"
+)
+
+func TestJSONEncoder_Encode(t *testing.T) {
+	res, err := JSONEncoder{}.Encode([]byte("prefix"), map[string]interface{}{"b": "<x>", "a": 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `prefix{"a":1,"b":"<x>"}`, string(res))
+
+	_, err = JSONEncoder{}.Encode(nil, func() {})
+
+	assert.Error(t, err)
+}
+
+type failingEncoder struct{}
+
+func (failingEncoder) Encode(dst []byte, v interface{}) ([]byte, error) {
+	return dst, errors.New("encode failed")
+}
+
+func TestElasticWriter_WriteDocument(t *testing.T) {
+	type document struct {
+		Time    string `json:"time"`
+		Message string `json:"message"`
+	}
+
+	doc := document{Time: "2020-01-02T03:04:05Z", Message: "test"}
+	source := "{\"time\":\"2020-01-02T03:04:05Z\",\"message\":\"test\"}\n"
+
+	tests := []struct {
+		name     string
+		write    func(w *ElasticWriter) error
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "Document",
+			write:    func(w *ElasticWriter) error { return w.WriteDocument(doc) },
+			expected: "{\"index\":{\"_index\":\"logs-2020.01.02\",\"pipeline\":\"default\"}}\n" + source,
+		},
+		{
+			name: "Fields",
+			write: func(w *ElasticWriter) error {
+				return w.WriteFields(map[string]interface{}{"time": doc.Time, "message": doc.Message})
+			},
+			expected: "{\"index\":{\"_index\":\"logs-2020.01.02\",\"pipeline\":\"default\"}}\n" +
+				"{\"message\":\"test\",\"time\":\"2020-01-02T03:04:05Z\"}\n",
+		},
+		{
+			name: "Meta",
+			write: func(w *ElasticWriter) error {
+				return w.WriteDocumentContext(context.Background(), doc, DocumentMeta{
+					Index:    "audit",
+					ID:       "1",
+					Pipeline: "audit",
+					Routing:  "user-1",
+				})
+			},
+			expected: "{\"index\":{\"_id\":\"1\",\"_index\":\"audit\",\"pipeline\":\"audit\",\"routing\":\"user-1\"}}\n" + source,
+		},
+		{
+			name: "InvalidIndex",
+			write: func(w *ElasticWriter) error {
+				return w.WriteDocumentContext(context.Background(), doc, DocumentMeta{Index: "Audit"})
+			},
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name: "InvalidID",
+			write: func(w *ElasticWriter) error {
+				return w.WriteDocumentContext(context.Background(), doc, DocumentMeta{ID: strings.Repeat("x", 513)})
+			},
+			expected: "",
+			wantErr:  true,
+		},
+		{
+			name: "EncoderError",
+			write: func(w *ElasticWriter) error {
+				w.encoder = failingEncoder{}
+
+				return w.WriteDocument(doc)
+			},
+			expected: "",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := &ElasticWriter{
+				batchSize:       1024,
+				indexName:       "logs",
+				timeFormat:      DefaultTimeFormat,
+				timestampFields: []string{"time"},
+				pipeline:        "default",
+				encoder:         JSONEncoder{},
+				transport:       &test.MockTransport{},
+				mu:              internal.NewMutex(),
+			}
+			writer.batch = writer.acquireBatch()
+
+			err := tt.write(writer)
+
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.expected, (*writer.batch).String())
+		})
+	}
+}
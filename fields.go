@@ -0,0 +1,145 @@
+package elw
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/gadavy/elw/internal"
+)
+
+// Environment variables of the pod, which are usually set by Kubernetes downward API.
+const (
+	EnvPodName      = "POD_NAME"
+	EnvPodNamespace = "POD_NAMESPACE"
+	EnvPodIP        = "POD_IP"
+	EnvNodeName     = "NODE_NAME"
+)
+
+// HostFields returns host.name field with the hostname, empty if it's unknown.
+func HostFields() map[string]interface{} {
+	fields := make(map[string]interface{})
+
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		fields["host.name"] = hostname
+	}
+
+	return fields
+}
+
+// KubernetesFields returns fields of the pod read from POD_NAME, POD_NAMESPACE,
+// POD_IP and NODE_NAME environment variables. Unset variables are skipped.
+func KubernetesFields() map[string]interface{} {
+	fields := make(map[string]interface{})
+
+	for env, key := range map[string]string{
+		EnvPodName:      "kubernetes.pod.name",
+		EnvPodNamespace: "kubernetes.namespace",
+		EnvPodIP:        "kubernetes.pod.ip",
+		EnvNodeName:     "kubernetes.node.name",
+	} {
+		if value := os.Getenv(env); value != "" {
+			fields[key] = value
+		}
+	}
+
+	return fields
+}
+
+// MergeFields returns union of fields, values of later maps win.
+func MergeFields(fields ...map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{})
+
+	for _, m := range fields {
+		for key, value := range m {
+			res[key] = value
+		}
+	}
+
+	return res
+}
+
+type staticField struct {
+	key   string
+	value []byte
+}
+
+// encodeStaticFields returns fields sorted by key with JSON encoded values
+// and all of them joined as raw JSON object fields.
+func encodeStaticFields(fields map[string]interface{}) ([]staticField, []byte, error) {
+	if len(fields) == 0 {
+		return nil, nil, nil
+	}
+
+	res := make([]staticField, 0, len(fields))
+
+	for key, value := range fields {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		res = append(res, staticField{key: key, value: data})
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].key < res[j].key })
+
+	return res, appendStaticFields(nil, res, nil), nil
+}
+
+// appendStaticFields appends fields which are not present in the doc as raw JSON object fields.
+func appendStaticFields(dst []byte, fields []staticField, doc []byte) []byte {
+	for _, f := range fields {
+		if doc != nil {
+			if _, ok := internal.LookupField(doc, f.key); ok {
+				continue
+			}
+		}
+
+		if len(dst) > 0 {
+			dst = append(dst, ',')
+		}
+
+		dst = internal.AppendQuoted(dst, f.key)
+		dst = append(dst, ':')
+		dst = append(dst, f.value...)
+	}
+
+	return dst
+}
+
+// addStaticFields returns the JSON object document with static fields.
+// Fields present in the document are kept unless overwriting is enabled.
+func (w *ElasticWriter) addStaticFields(doc []byte) []byte {
+	if len(w.staticFields) == 0 || len(doc) == 0 || doc[0] != '{' {
+		return doc
+	}
+
+	fields := w.staticFieldsRaw
+	present := false
+
+	for _, f := range w.staticFields {
+		if _, ok := internal.LookupField(doc, f.key); ok {
+			present = true
+
+			break
+		}
+	}
+
+	if present {
+		if w.overwriteStaticFields {
+			for _, f := range w.staticFields {
+				if replaced, ok := internal.ReplaceField(nil, doc, f.key, f.value); ok {
+					doc = replaced
+				}
+			}
+		}
+
+		w.fieldsBuf = appendStaticFields(w.fieldsBuf[:0], w.staticFields, doc)
+		fields = w.fieldsBuf
+	}
+
+	w.enrichBuf = internal.InsertFields(w.enrichBuf[:0], doc, fields)
+
+	return w.enrichBuf
+}
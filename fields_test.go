@@ -0,0 +1,101 @@
+package elw
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElasticWriter_addStaticFields(t *testing.T) {
+	fields, raw, err := encodeStaticFields(map[string]interface{}{
+		"service": "api",
+		"version": 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		overwrite bool
+		doc       string
+		expected  string
+	}{
+		{
+			name:     "Missing",
+			doc:      `{"message":"test"}`,
+			expected: `{"service":"api","version":2,"message":"test"}`,
+		},
+		{
+			name:     "Present",
+			doc:      `{"service":"worker","message":"test"}`,
+			expected: `{"version":2,"service":"worker","message":"test"}`,
+		},
+		{
+			name:      "Overwrite",
+			overwrite: true,
+			doc:       `{"service":"worker","message":"test"}`,
+			expected:  `{"version":2,"service":"api","message":"test"}`,
+		},
+		{
+			name:     "NotObject",
+			doc:      `plain text`,
+			expected: `plain text`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := &ElasticWriter{
+				staticFields:          fields,
+				staticFieldsRaw:       raw,
+				overwriteStaticFields: tt.overwrite,
+			}
+
+			assert.Equal(t, tt.expected, string(writer.addStaticFields([]byte(tt.doc))))
+		})
+	}
+}
+
+func TestEncodeStaticFields(t *testing.T) {
+	_, raw, err := encodeStaticFields(map[string]interface{}{
+		`quote"key`:  1,
+		`back\slash`: 2,
+		"service":    "api",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `"back\\slash":2,"quote\"key":1,"service":"api"`, string(raw))
+}
+
+func TestKubernetesFields(t *testing.T) {
+	for env, value := range map[string]string{EnvPodName: "api-0", EnvPodNamespace: "default", EnvPodIP: "", EnvNodeName: ""} {
+		old, ok := os.LookupEnv(env)
+
+		_ = os.Setenv(env, value)
+
+		defer func(env, old string, ok bool) {
+			if ok {
+				_ = os.Setenv(env, old)
+			} else {
+				_ = os.Unsetenv(env)
+			}
+		}(env, old, ok)
+	}
+
+	expected := map[string]interface{}{
+		"kubernetes.pod.name":  "api-0",
+		"kubernetes.namespace": "default",
+	}
+
+	assert.Equal(t, expected, KubernetesFields())
+}
+
+func TestMergeFields(t *testing.T) {
+	res := MergeFields(
+		map[string]interface{}{"a": 1, "b": 1},
+		map[string]interface{}{"b": 2},
+	)
+
+	assert.Equal(t, map[string]interface{}{"a": 1, "b": 2}, res)
+}
@@ -0,0 +1,34 @@
+package elw
+
+import (
+	"github.com/gadavy/elw/transport"
+)
+
+// Hooks receives events of the writer and its nodes. Methods are called by senders
+// and must be safe for concurrent use and fast. Embed NopHooks to implement only some of them.
+type Hooks interface {
+	transport.Hooks
+
+	// OnBatchSent is called when the batch is accepted by Elasticsearch.
+	OnBatchSent(size int)
+	// OnBatchStored is called when the batch which wasn't sent is put to the storage.
+	OnBatchStored(size int, err error)
+	// OnBatchDropped is called when the batch is lost, e.g. by overflow policy
+	// or because the storage failed.
+	OnBatchDropped(size int, err error)
+	// OnReplay is called for each batch sent from the storage, err is not nil
+	// if the batch is put back.
+	OnReplay(size int, err error)
+}
+
+// NopHooks ignores all events.
+type NopHooks struct{}
+
+func (NopHooks) OnNodeDown(string, error)  {}
+func (NopHooks) OnNodeUp(string)           {}
+func (NopHooks) OnDisconnected()           {}
+func (NopHooks) OnReconnected()            {}
+func (NopHooks) OnBatchSent(int)           {}
+func (NopHooks) OnBatchStored(int, error)  {}
+func (NopHooks) OnBatchDropped(int, error) {}
+func (NopHooks) OnReplay(int, error)       {}
@@ -0,0 +1,83 @@
+package elw
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gadavy/elw/batch"
+	"github.com/gadavy/elw/storage"
+	"github.com/gadavy/elw/test"
+)
+
+type recordingHooks struct {
+	NopHooks
+
+	mu     sync.Mutex
+	events []string
+}
+
+func (h *recordingHooks) record(format string, v ...interface{}) {
+	h.mu.Lock()
+	h.events = append(h.events, fmt.Sprintf(format, v...))
+	h.mu.Unlock()
+}
+
+func (h *recordingHooks) OnBatchSent(size int)               { h.record("sent %d", size) }
+func (h *recordingHooks) OnBatchStored(size int, err error)  { h.record("stored %d: %v", size, err) }
+func (h *recordingHooks) OnBatchDropped(size int, err error) { h.record("dropped %d: %v", size, err) }
+func (h *recordingHooks) OnReplay(size int, err error)       { h.record("replay %d: %v", size, err) }
+func (h *recordingHooks) OnNodeDown(host string, err error)  { h.record("down %s: %v", host, err) }
+func (h *recordingHooks) OnNodeUp(host string)               { h.record("up %s", host) }
+func (h *recordingHooks) OnDisconnected()                    { h.record("disconnected") }
+func (h *recordingHooks) OnReconnected()                     { h.record("reconnected") }
+
+func TestElasticWriter_hooks(t *testing.T) {
+	sendErr := errors.New("transport error")
+
+	tr := &test.MockTransport{}
+	tr.On("IsConnected").Return(true, true, true, false)
+	tr.On("SendBulk", []byte("sent\n")).Return(nil)
+	tr.On("SendBulk", []byte("failed\n")).Return(sendErr)
+
+	st, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hooks := &recordingHooks{}
+
+	writer := &ElasticWriter{
+		transport: tr,
+		storage:   st,
+		hooks:     hooks,
+		wg:        new(sync.WaitGroup),
+	}
+
+	for _, body := range []string{"sent", "failed"} {
+		b := batch.NewBatch(0)
+		b.AppendBytes([]byte(body))
+
+		writer.wg.Add(1)
+		writer.releaseBatch(b, nil)
+	}
+
+	// failed batch is replayed and put back.
+	writer.releaseStorage()
+
+	b := batch.NewBatch(0)
+	b.AppendBytes([]byte("dropped"))
+
+	writer.wg.Add(1)
+	writer.dropBatch(queuedBatch{batch: b})
+
+	assert.Equal(t, []string{
+		"sent 5",
+		"stored 7: transport error",
+		"replay 7: transport error",
+		"dropped 8: batch dropped by overflow policy",
+	}, hooks.events)
+}
@@ -0,0 +1,132 @@
+package elw
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+
+	"github.com/gadavy/elw/batch"
+	"github.com/gadavy/elw/transport"
+)
+
+// IDStrategy defines how IDs of documents are generated. IDs are kept in metadata
+// of stored batches, so resent documents overwrite previous copies instead of duplicating.
+// Stored batches are marked with the strategy of the writer that stored them.
+type IDStrategy int
+
+const (
+	// IDNone lets Elasticsearch generate IDs.
+	IDNone IDStrategy = iota
+	// IDContentHash uses hash of the document source, so equal documents
+	// written into the same index are stored once.
+	IDContentHash
+	// IDSequence uses instance ID and sequence number of the document in the writer.
+	IDSequence
+)
+
+// storedIDsPrefix starts the header line of stored batches with generated IDs.
+// The header isn't a bulk action, so it's removed before the batch is replayed.
+const storedIDsPrefix = "#ids="
+
+func (s IDStrategy) String() string {
+	switch s {
+	case IDNone:
+		return "none"
+	case IDContentHash:
+		return "content_hash"
+	case IDSequence:
+		return "sequence"
+	default:
+		return "unknown"
+	}
+}
+
+func parseIDStrategy(s string) IDStrategy {
+	switch s {
+	case "content_hash":
+		return IDContentHash
+	case "sequence":
+		return IDSequence
+	default:
+		return IDNone
+	}
+}
+
+// storedBatch returns the body to put to the storage, prefixed with the header
+// of the ID strategy unless IDs are generated by Elasticsearch.
+func (w *ElasticWriter) storedBatch(body []byte) []byte {
+	if w.idStrategy == IDNone {
+		return body
+	}
+
+	ids := w.idStrategy.String()
+
+	res := make([]byte, 0, len(storedIDsPrefix)+len(ids)+1+len(body))
+	res = append(res, storedIDsPrefix...)
+	res = append(res, ids...)
+	res = append(res, '\n')
+
+	return append(res, body...)
+}
+
+// parseStoredBatch returns the ID strategy of the stored batch and its body without the header.
+// Batches without the header are stored with IDNone.
+func parseStoredBatch(data []byte) (IDStrategy, []byte) {
+	if !bytes.HasPrefix(data, []byte(storedIDsPrefix)) {
+		return IDNone, data
+	}
+
+	i := bytes.IndexByte(data, '\n')
+	if i < 0 {
+		return IDNone, nil
+	}
+
+	return parseIDStrategy(string(data[len(storedIDsPrefix):i])), data[i+1:]
+}
+
+// newInstanceID returns random ID of the writer instance.
+func newInstanceID() string {
+	buf := make([]byte, 8)
+
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(buf)
+}
+
+// documentID returns ID of the document source according to the strategy, nil for IDNone.
+func (w *ElasticWriter) documentID(source []byte) []byte {
+	switch w.idStrategy {
+	case IDContentHash:
+		h := fnv.New128a()
+		_, _ = h.Write(source)
+
+		// hash and its hex representation share the buffer.
+		w.idBuf = h.Sum(w.idBuf[:0])
+		w.idBuf = append(w.idBuf, make([]byte, hex.EncodedLen(len(w.idBuf)))...)
+
+		n := len(w.idBuf) / 3
+		hex.Encode(w.idBuf[n:], w.idBuf[:n])
+
+		return w.idBuf[n:]
+	case IDSequence:
+		w.idSequence++
+
+		w.idBuf = append(w.idBuf[:0], w.instanceID...)
+		w.idBuf = append(w.idBuf, '-')
+
+		return strconv.AppendUint(w.idBuf, w.idSequence, 36)
+	default:
+		return nil
+	}
+}
+
+// isDuplicate reports whether the action failed because the document with the same ID
+// was created before, so the document is already delivered.
+func isDuplicate(failed transport.FailedItem, item batch.Item) bool {
+	return failed.Status == http.StatusConflict && failed.Action == batch.ActionCreate && item.ID() != ""
+}
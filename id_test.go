@@ -0,0 +1,110 @@
+package elw
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gadavy/elw/storage"
+	"github.com/gadavy/elw/test"
+	"github.com/gadavy/elw/transport"
+)
+
+func TestElasticWriter_documentID(t *testing.T) {
+	t.Run("None", func(t *testing.T) {
+		writer := &ElasticWriter{}
+
+		assert.Nil(t, writer.documentID([]byte(`{"a":1}`)))
+	})
+
+	t.Run("ContentHash", func(t *testing.T) {
+		writer := &ElasticWriter{idStrategy: IDContentHash}
+
+		first := string(writer.documentID([]byte(`{"a":1}`)))
+		second := string(writer.documentID([]byte(`{"a":2}`)))
+
+		assert.Len(t, first, 32)
+		assert.NotEqual(t, first, second)
+		assert.Equal(t, first, string(writer.documentID([]byte(`{"a":1}`))))
+	})
+
+	t.Run("Sequence", func(t *testing.T) {
+		writer := &ElasticWriter{idStrategy: IDSequence, instanceID: "instance"}
+
+		assert.Equal(t, "instance-1", string(writer.documentID([]byte(`{"a":1}`))))
+		assert.Equal(t, "instance-2", string(writer.documentID([]byte(`{"a":1}`))))
+	})
+}
+
+func TestElasticWriter_retryFailedDuplicate(t *testing.T) {
+	var rejections []Rejection
+
+	body := []byte("{\"create\":{\"_id\":\"instance-1\",\"_index\":\"logs\"}}\n{\"a\":1}\n{\"create\":{\"_index\":\"logs\"}}\n{\"b\":2}\n")
+
+	writer := &ElasticWriter{
+		onReject: func(r Rejection) {
+			rejections = append(rejections, r)
+		},
+	}
+
+	conflict := func(position int) transport.FailedItem {
+		return transport.FailedItem{
+			Position: position,
+			Action:   "create",
+			BulkItem: transport.BulkItem{
+				Index:  "logs",
+				Status: 409,
+				Error:  &transport.BulkItemReason{Type: "version_conflict_engine_exception"},
+			},
+		}
+	}
+
+	retried := writer.retryFailed(body, &transport.BulkError{Items: []transport.FailedItem{conflict(0), conflict(1)}}, nil)
+
+	assert.False(t, retried)
+
+	if assert.Len(t, rejections, 1) {
+		assert.Equal(t, `{"b":2}`, string(rejections[0].Document))
+	}
+}
+
+func TestElasticWriter_storedBatch(t *testing.T) {
+	body := []byte("{\"index\":{\"_id\":\"instance-1\",\"_index\":\"logs\"}}\n{\"a\":1}\n")
+
+	tests := []struct {
+		name     string
+		strategy IDStrategy
+		stored   string
+	}{
+		{name: "None", strategy: IDNone, stored: string(body)},
+		{name: "ContentHash", strategy: IDContentHash, stored: "#ids=content_hash\n" + string(body)},
+		{name: "Sequence", strategy: IDSequence, stored: "#ids=sequence\n" + string(body)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, _ := storage.New(":memory:")
+
+			tr := &test.MockTransport{}
+			tr.On("IsConnected").Return(true, true)
+			tr.On("SendBulk", body).Return(nil)
+
+			writer := &ElasticWriter{idStrategy: tt.strategy, transport: tr, storage: st}
+
+			assert.NoError(t, writer.storeBatch(body, nil, nil))
+
+			stored, _ := st.Pop()
+			assert.Equal(t, tt.stored, string(stored))
+
+			ids, res := parseStoredBatch(stored)
+			assert.Equal(t, tt.strategy, ids)
+			assert.Equal(t, body, res)
+
+			// header is removed before replay.
+			_ = st.Put(stored)
+			writer.releaseStorage()
+
+			tr.AssertExpectations(t)
+			assert.False(t, st.IsUsed())
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package elw
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gadavy/elw/internal"
+)
+
+const (
+	datePlaceholder   = "date"
+	defaultFieldValue = "unknown"
+)
+
+// Rules of index names refused by Elasticsearch, shared by validation and sanitizing.
+const (
+	maxIndexNameSize     = 255
+	invalidIndexChars    = `\/*?"<>|,#: `
+	invalidIndexPrefixes = "-_+"
+)
+
+// indexTemplate resolves index name of each document from its top-level fields.
+// Template like logs-{service}-{level|info}-{date} contains placeholders
+// with field names and optional fallback values after the pipe. Missing fields
+// without fallback are replaced with "unknown", {date} is replaced with the document date.
+type indexTemplate []templatePart
+
+type templatePart struct {
+	literal  string
+	field    string
+	fallback string
+}
+
+func parseIndexTemplate(s string) indexTemplate {
+	var t indexTemplate
+
+	for len(s) > 0 {
+		start := strings.IndexByte(s, '{')
+		end := strings.IndexByte(s, '}')
+
+		if start < 0 || end < start {
+			t = append(t, templatePart{literal: s})
+
+			break
+		}
+
+		if start > 0 {
+			t = append(t, templatePart{literal: s[:start]})
+		}
+
+		part := templatePart{field: s[start+1 : end], fallback: defaultFieldValue}
+
+		if i := strings.IndexByte(part.field, '|'); i >= 0 {
+			part.field, part.fallback = part.field[:i], part.field[i+1:]
+		}
+
+		t = append(t, part)
+		s = s[end+1:]
+	}
+
+	return t
+}
+
+// resolve appends index name of the document to dst. The fallback is used instead
+// of the name which is empty after sanitizing, e.g. resolved from "..".
+func (t indexTemplate) resolve(dst, doc []byte, now time.Time, timeFormat, fallback string) []byte {
+	start := len(dst)
+
+	for _, part := range t {
+		switch part.field {
+		case "":
+			dst = append(dst, part.literal...)
+		case datePlaceholder:
+			dst = now.AppendFormat(dst, timeFormat)
+		default:
+			value, ok := internal.LookupString(doc, part.field)
+			if !ok || value == "" {
+				value = part.fallback
+			}
+
+			dst = append(dst, value...)
+		}
+	}
+
+	if dst = sanitizeIndexName(dst, start); len(dst) > start {
+		return dst
+	}
+
+	if dst = sanitizeIndexName(append(dst, fallback...), start); len(dst) > start {
+		return dst
+	}
+
+	return append(dst, DefaultIndexName...)
+}
+
+// sanitizeIndexName makes dst[start:] a valid lowercase index name.
+func sanitizeIndexName(dst []byte, start int) []byte {
+	name := dst[start:]
+
+	for i, c := range name {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			name[i] = c + 'a' - 'A'
+		case strings.IndexByte(invalidIndexChars, c) >= 0:
+			name[i] = '_'
+		}
+	}
+
+	var trim int
+
+	// leading dots are trimmed too, so names are neither hidden nor . and ..
+	for trim < len(name) && (name[trim] == '.' || strings.IndexByte(invalidIndexPrefixes, name[trim]) >= 0) {
+		trim++
+	}
+
+	name = append(name[:0], name[trim:]...)
+
+	if len(name) > maxIndexNameSize {
+		name = name[:maxIndexNameSize]
+	}
+
+	return dst[:start+len(name)]
+}
@@ -0,0 +1,90 @@
+package elw
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIndexTemplate(t *testing.T) {
+	expected := indexTemplate{
+		{literal: "logs-"},
+		{field: "service", fallback: defaultFieldValue},
+		{literal: "-"},
+		{field: "level", fallback: "info"},
+		{literal: "-"},
+		{field: datePlaceholder, fallback: defaultFieldValue},
+		{literal: "-{tail"},
+	}
+
+	assert.Equal(t, expected, parseIndexTemplate("logs-{service}-{level|info}-{date}-{tail"))
+}
+
+func TestIndexTemplate_resolve(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		template string
+		doc      string
+		fallback string
+		expected string
+	}{
+		{
+			name:     "Fields",
+			template: "logs-{service}-{level}-{date}",
+			doc:      `{"service":"Billing API","level":"error"}`,
+			expected: "logs-billing_api-error-2020.01.02",
+		},
+		{
+			name:     "Fallback",
+			template: "logs-{service}-{level|info}-{date}",
+			doc:      `{"message":"test"}`,
+			expected: "logs-unknown-info-2020.01.02",
+		},
+		{
+			name:     "InvalidDocument",
+			template: "logs-{service}",
+			doc:      `message`,
+			expected: "logs-unknown",
+		},
+		{
+			name:     "InvalidCharacters",
+			template: "{service}-{date}",
+			doc:      `{"service":"_A/b*c?d\"e<f>g|h,i#j:k"}`,
+			expected: "a_b_c_d_e_f_g_h_i_j_k-2020.01.02",
+		},
+		{
+			name:     "EmptyName",
+			template: "{service}",
+			doc:      `{"service":".."}`,
+			expected: "fallback",
+		},
+		{
+			name:     "EmptyFallback",
+			template: "{service}{level}",
+			doc:      `{"service":"__","level":"-"}`,
+			fallback: "..",
+			expected: DefaultIndexName,
+		},
+		{
+			name:     "Number",
+			template: "logs-{code}",
+			doc:      `{"code":500}`,
+			expected: "logs-500",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.fallback == "" {
+				tt.fallback = "Fallback"
+			}
+
+			res := parseIndexTemplate(tt.template).resolve([]byte("prefix"), []byte(tt.doc), now, DefaultTimeFormat, tt.fallback)
+
+			assert.Equal(t, "prefix"+tt.expected, string(res))
+			assert.NoError(t, validateIndexName(string(res[len("prefix"):])))
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package elw
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gadavy/elw/batch"
+	"github.com/gadavy/elw/transport"
+)
+
+const (
+	// Default bulk indexer settings
+	DefaultFlushItems = 1000
+)
+
+var (
+	ErrIndexerClosed = errors.New("bulk indexer is closed")
+	ErrUnknownAction = errors.New("unknown bulk action")
+	ErrNoIndex       = errors.New("index is required")
+	ErrNoID          = errors.New("id is required by update and delete actions")
+	ErrNoBody        = errors.New("body is required by index, create and update actions")
+	// ErrActionStored is passed to OnFailure of actions put to the storage,
+	// they are sent on reconnect without callbacks.
+	ErrActionStored = errors.New("action put to storage")
+)
+
+// ActionError is passed to OnFailure of actions rejected by Elasticsearch.
+type ActionError struct {
+	transport.FailedItem
+}
+
+func (e *ActionError) Error() string {
+	if e.BulkItem.Error == nil {
+		return fmt.Sprintf("%s action rejected by index %s with status %d", e.Action, e.Index, e.Status)
+	}
+
+	return fmt.Sprintf("%s action rejected by index %s with status %d: %s: %s",
+		e.Action, e.Index, e.Status, e.BulkItem.Error.Type, e.BulkItem.Error.Reason)
+}
+
+// BulkIndexerItem is a single action of the bulk request.
+type BulkIndexerItem struct {
+	// Action is index, create, update or delete, index if empty.
+	Action string
+	Index  string
+	// ID of the document, required by update and delete actions.
+	ID       string
+	Routing  string
+	Pipeline string
+	// Body is a document of index and create actions or a partial document
+	// or a script of update action, e.g. {"doc":{"a":1}}. Ignored by delete action.
+	Body []byte
+
+	// OnSuccess is called when the action is accepted by Elasticsearch.
+	OnSuccess func(item BulkIndexerItem)
+	// OnFailure is called with *ActionError when the action is rejected, ErrActionStored when
+	// the action is put to the storage or error of the request which was neither sent nor stored.
+	OnFailure func(item BulkIndexerItem, err error)
+}
+
+type BulkIndexerConfig struct {
+	// FlushBytes is a size of the request body which is sent, DefaultBatchSize by default.
+	FlushBytes int
+	// FlushItems is a number of actions which are sent, DefaultFlushItems by default.
+	FlushItems int
+	// FlushInterval is a period after which actions are sent anyway, DefaultRotatePeriod by default.
+	FlushInterval time.Duration
+	// Workers is a number of goroutines sending requests, DefaultWorkers by default.
+	Workers int
+	// QueueSize is a number of flushed requests waiting for a free worker.
+	QueueSize int
+	// OverflowPolicy defines what to do with a flushed request when the queue is full,
+	// OverflowSpill by default.
+	OverflowPolicy OverflowPolicy
+
+	// OnReject receives documents of stored requests rejected by Elasticsearch,
+	// actions passed to Add are reported to their OnFailure instead.
+	OnReject RejectHandler
+	// Logger, LogRateLimit and Hooks are the same as ones of Config.
+	Logger       Logger
+	LogRateLimit time.Duration
+	Hooks        Hooks
+
+	// Transport settings
+	NodeURIs       []string
+	RequestTimeout time.Duration
+	PingInterval   time.Duration
+	UserAgent      string
+	BulkParams     transport.BulkParams
+
+	// Storage settings
+	Filepath    string
+	DropStorage bool
+}
+
+// BulkIndexerStats contains counters of actions by outcome and statistics of requests.
+type BulkIndexerStats struct {
+	Stats
+
+	Added     uint64
+	Flushed   uint64
+	Succeeded uint64
+	Failed    uint64
+	Stored    uint64
+}
+
+// BulkIndexer sends actions of any kind in batches flushed by size, number of actions
+// and interval. Batches are sent by the senders of ElasticWriter, so they share its queue,
+// overflow policy, storage, hooks and logger. Methods are safe for concurrent use.
+type BulkIndexer struct {
+	added uint64 // must be the first field to be 64-bit aligned.
+
+	writer     *ElasticWriter
+	flushItems int
+	closed     bool
+}
+
+func NewBulkIndexer(cfg BulkIndexerConfig) (*BulkIndexer, error) {
+	if cfg.FlushBytes <= 0 {
+		cfg.FlushBytes = DefaultBatchSize
+	}
+
+	if cfg.FlushItems <= 0 {
+		cfg.FlushItems = DefaultFlushItems
+	}
+
+	w, err := NewElasticWriter(Config{
+		BatchSize:      cfg.FlushBytes,
+		RotatePeriod:   cfg.FlushInterval,
+		Workers:        cfg.Workers,
+		QueueSize:      cfg.QueueSize,
+		OverflowPolicy: cfg.OverflowPolicy,
+		OnReject:       cfg.OnReject,
+		Logger:         cfg.Logger,
+		LogRateLimit:   cfg.LogRateLimit,
+		Hooks:          cfg.Hooks,
+		NodeURIs:       cfg.NodeURIs,
+		RequestTimeout: cfg.RequestTimeout,
+		PingInterval:   cfg.PingInterval,
+		UserAgent:      cfg.UserAgent,
+		BulkParams:     cfg.BulkParams,
+		Filepath:       cfg.Filepath,
+		DropStorage:    cfg.DropStorage,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newBulkIndexer(w, cfg.FlushItems), nil
+}
+
+func newBulkIndexer(w *ElasticWriter, flushItems int) *BulkIndexer {
+	return &BulkIndexer{writer: w, flushItems: flushItems}
+}
+
+// Add appends the action to the current batch, waiting for a free sender
+// only until the context is done. Batch that can't be sent in time is handled by OverflowPolicy.
+// ErrInvalidIndex and ErrInvalidID are returned for Index and ID refused by Elasticsearch.
+func (bi *BulkIndexer) Add(ctx context.Context, item BulkIndexerItem) error {
+	if item.Action == "" {
+		item.Action = batch.ActionIndex
+	}
+
+	if item.Index == "" {
+		return ErrNoIndex
+	}
+
+	if err := validateIndexName(item.Index); err != nil {
+		return err
+	}
+
+	if item.ID == "" && (item.Action == batch.ActionUpdate || item.Action == batch.ActionDelete) {
+		return ErrNoID
+	}
+
+	if err := validateDocumentID(item.ID); err != nil {
+		return err
+	}
+
+	switch item.Action {
+	case batch.ActionIndex, batch.ActionCreate, batch.ActionUpdate:
+		if len(item.Body) == 0 {
+			return ErrNoBody
+		}
+
+		// newlines separate actions of the request.
+		if bytes.IndexByte(item.Body, '\n') >= 0 {
+			buf := bytes.NewBuffer(make([]byte, 0, len(item.Body)))
+
+			if err := json.Compact(buf, item.Body); err != nil {
+				return err
+			}
+
+			item.Body = buf.Bytes()
+		}
+	case batch.ActionDelete:
+		item.Body = nil
+	default:
+		return ErrUnknownAction
+	}
+
+	w := bi.writer
+
+	if err := w.mu.LockContext(ctx); err != nil {
+		return err
+	}
+
+	defer w.mu.Unlock()
+
+	if bi.closed {
+		return ErrIndexerClosed
+	}
+
+	if len(w.actions) > 0 && (*w.batch).Len()+len(item.Body) > w.batchSize {
+		w.rotateBatch(ctx)
+	}
+
+	w.appendAction(item)
+	atomic.AddUint64(&bi.added, 1)
+
+	if len(w.actions) >= bi.flushItems || (*w.batch).Len() >= w.batchSize {
+		w.rotateBatch(ctx)
+	}
+
+	return nil
+}
+
+// Flush sends the current batch and waits until all added actions are sent or stored,
+// only until the context is done. Errors are the same as ones of ElasticWriter.SyncContext.
+func (bi *BulkIndexer) Flush(ctx context.Context) error {
+	return bi.writer.SyncContext(ctx)
+}
+
+// Close sends the current batch and the storage, waiting for delivery until the context is done.
+// Storage is dropped with DropStorage only if everything is delivered.
+func (bi *BulkIndexer) Close(ctx context.Context) error {
+	w := bi.writer
+
+	if err := w.mu.LockContext(ctx); err != nil {
+		return w.undelivered(err)
+	}
+
+	closed := bi.closed
+	bi.closed = true
+
+	w.mu.Unlock()
+
+	if closed {
+		return nil
+	}
+
+	return w.CloseContext(ctx)
+}
+
+// Stats returns counters of actions and statistics of requests, safe for concurrent use.
+func (bi *BulkIndexer) Stats() BulkIndexerStats {
+	w := bi.writer
+
+	return BulkIndexerStats{
+		Stats:     w.Stats(),
+		Added:     atomic.LoadUint64(&bi.added),
+		Flushed:   atomic.LoadUint64(&w.stats.actionsFlushed),
+		Succeeded: atomic.LoadUint64(&w.stats.actionsSucceeded),
+		Failed:    atomic.LoadUint64(&w.stats.actionsFailed),
+		Stored:    atomic.LoadUint64(&w.stats.actionsStored),
+	}
+}
+
+// appendAction appends the action of the bulk indexer to the current batch, the writer must be locked.
+func (w *ElasticWriter) appendAction(item BulkIndexerItem) {
+	(*w.batch).AppendAction(batch.Meta{
+		Action:   item.Action,
+		Index:    []byte(item.Index),
+		ID:       []byte(item.ID),
+		Pipeline: item.Pipeline,
+		Routing:  item.Routing,
+	})
+
+	if item.Action != batch.ActionDelete {
+		(*w.batch).AppendBytes(item.Body)
+	}
+
+	w.actions = append(w.actions, item)
+}
@@ -0,0 +1,313 @@
+package elw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gadavy/elw/internal"
+	"github.com/gadavy/elw/storage"
+	"github.com/gadavy/elw/test"
+	"github.com/gadavy/elw/transport"
+)
+
+// bulkTransport records bulk requests and responds with errors in order.
+type bulkTransport struct {
+	test.StubTransport
+
+	mu           sync.Mutex
+	disconnected bool
+	bodies       []string
+	errs         []error
+}
+
+func (t *bulkTransport) SendBulk(body []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.bodies = append(t.bodies, string(body))
+
+	if len(t.errs) == 0 {
+		return nil
+	}
+
+	err := t.errs[0]
+	t.errs = t.errs[1:]
+
+	return err
+}
+
+func (t *bulkTransport) IsConnected() bool {
+	return !t.disconnected
+}
+
+func (t *bulkTransport) requests() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]string(nil), t.bodies...)
+}
+
+// itemResults collects results of item callbacks by ID.
+type itemResults struct {
+	mu      sync.Mutex
+	results map[string]error
+}
+
+func (r *itemResults) item(action, id, body string) BulkIndexerItem {
+	return BulkIndexerItem{
+		Action: action,
+		Index:  "test",
+		ID:     id,
+		Body:   []byte(body),
+		OnSuccess: func(item BulkIndexerItem) {
+			r.set(item.ID, nil)
+		},
+		OnFailure: func(item BulkIndexerItem, err error) {
+			r.set(item.ID, err)
+		},
+	}
+}
+
+func (r *itemResults) set(id string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.results == nil {
+		r.results = make(map[string]error)
+	}
+
+	r.results[id] = err
+}
+
+// newTestIndexer returns the indexer started with a single sender.
+func newTestIndexer(tr transport.Transport, st storage.Storage, flushItems int, flushInterval time.Duration) *BulkIndexer {
+	w := &ElasticWriter{
+		batchSize: DefaultBatchSize,
+		transport: tr,
+		storage:   st,
+		mu:        internal.NewMutex(),
+		wg:        new(sync.WaitGroup),
+		done:      make(internal.Signal, 1),
+		queue:     make(chan queuedBatch, 1),
+		stop:      make(chan struct{}),
+
+		rotatePeriod: flushInterval,
+		timer:        time.NewTimer(flushInterval),
+	}
+
+	w.batch = w.acquireBatch()
+
+	go w.sender()
+	go w.worker()
+
+	return newBulkIndexer(w, flushItems)
+}
+
+func TestBulkIndexer_Add(t *testing.T) {
+	const (
+		indexAction  = "{\"index\":{\"_id\":\"1\",\"_index\":\"test\"}}\n{\"a\":1}\n"
+		updateAction = "{\"update\":{\"_id\":\"2\",\"_index\":\"test\"}}\n{\"doc\":{\"a\":2}}\n"
+		deleteAction = "{\"delete\":{\"_id\":\"3\",\"_index\":\"test\"}}\n"
+	)
+
+	rejected := transport.FailedItem{
+		Position: 0,
+		Action:   "index",
+		BulkItem: transport.BulkItem{
+			Index:  "test",
+			Status: http.StatusBadRequest,
+			Error:  &transport.BulkItemReason{Type: "mapper_parsing_exception", Reason: "failed to parse"},
+		},
+	}
+	overloaded := transport.FailedItem{
+		Position: 1,
+		Action:   "update",
+		BulkItem: transport.BulkItem{Index: "test", Status: http.StatusTooManyRequests},
+	}
+
+	tests := []struct {
+		name             string
+		disconnected     bool
+		errs             []error
+		expectedRequests []string
+		expectedResults  map[string]error
+		expectedStorage  string
+	}{
+		{
+			name:             "Success",
+			expectedRequests: []string{indexAction + updateAction + deleteAction},
+			expectedResults:  map[string]error{"1": nil, "2": nil, "3": nil},
+		},
+		{
+			name:             "PartialFailure",
+			errs:             []error{&transport.BulkError{Items: []transport.FailedItem{rejected, overloaded}}},
+			expectedRequests: []string{indexAction + updateAction + deleteAction},
+			expectedResults:  map[string]error{"1": &ActionError{FailedItem: rejected}, "2": ErrActionStored, "3": nil},
+			expectedStorage:  updateAction,
+		},
+		{
+			name:             "RequestTooLarge",
+			errs:             []error{transport.ErrRequestTooLarge},
+			expectedRequests: []string{indexAction + updateAction + deleteAction, indexAction, updateAction + deleteAction},
+			expectedResults:  map[string]error{"1": nil, "2": nil, "3": nil},
+		},
+		{
+			name:            "Disconnected",
+			disconnected:    true,
+			expectedResults: map[string]error{"1": ErrActionStored, "2": ErrActionStored, "3": ErrActionStored},
+			expectedStorage: indexAction + updateAction + deleteAction,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := &bulkTransport{disconnected: tt.disconnected, errs: tt.errs}
+			st, _ := storage.New(":memory:")
+			results := &itemResults{}
+
+			indexer := newTestIndexer(tr, st, 3, time.Hour)
+
+			assert.NoError(t, indexer.Add(context.Background(), results.item("", "1", "{\n\"a\": 1\n}")))
+			assert.NoError(t, indexer.Add(context.Background(), results.item("update", "2", `{"doc":{"a":2}}`)))
+			assert.NoError(t, indexer.Add(context.Background(), results.item("delete", "3", "")))
+
+			assert.NoError(t, indexer.Flush(context.Background()))
+
+			assert.Equal(t, tt.expectedRequests, tr.requests())
+			assert.Equal(t, tt.expectedResults, results.results)
+
+			stored, _ := st.Pop()
+			assert.Equal(t, tt.expectedStorage, string(stored))
+
+			assert.NoError(t, indexer.Close(context.Background()))
+			assert.Equal(t, ErrIndexerClosed, indexer.Add(context.Background(), results.item("", "4", "{}")))
+		})
+	}
+}
+
+func TestBulkIndexer_AddInvalid(t *testing.T) {
+	indexer := newTestIndexer(&bulkTransport{}, &test.StubStorage{}, 1, time.Hour)
+	defer indexer.Close(context.Background())
+
+	tests := []struct {
+		name string
+		item BulkIndexerItem
+		err  error
+	}{
+		{name: "NoIndex", item: BulkIndexerItem{Body: []byte("{}")}, err: ErrNoIndex},
+		{name: "NoID", item: BulkIndexerItem{Action: "delete", Index: "test"}, err: ErrNoID},
+		{name: "NoBody", item: BulkIndexerItem{Action: "create", Index: "test"}, err: ErrNoBody},
+		{name: "UnknownAction", item: BulkIndexerItem{Action: "upsert", Index: "test", ID: "1"}, err: ErrUnknownAction},
+		{name: "InvalidIndex", item: BulkIndexerItem{Action: "delete", Index: `users"x`, ID: "1"}, err: ErrInvalidIndex},
+		{name: "InvalidID", item: BulkIndexerItem{Action: "delete", Index: "users", ID: strings.Repeat("x", 513)}, err: ErrInvalidID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.err, indexer.Add(context.Background(), tt.item))
+		})
+	}
+
+	assert.Error(t, indexer.Add(context.Background(), BulkIndexerItem{Index: "test", Body: []byte("{\n")}))
+	assert.Equal(t, uint64(0), indexer.Stats().Added)
+}
+
+func TestBulkIndexer_FlushInterval(t *testing.T) {
+	tr := &bulkTransport{}
+	st, _ := storage.New(":memory:")
+	_ = st.Put([]byte("{\"delete\":{\"_id\":\"0\",\"_index\":\"test\"}}\n"))
+
+	indexer := newTestIndexer(tr, st, DefaultFlushItems, 10*time.Millisecond)
+
+	succeeded := make(chan struct{})
+
+	err := indexer.Add(context.Background(), BulkIndexerItem{
+		Index:     "test",
+		Body:      []byte(`{"a":1}`),
+		OnSuccess: func(BulkIndexerItem) { close(succeeded) },
+	})
+	assert.NoError(t, err)
+
+	select {
+	case <-succeeded:
+	case <-time.After(time.Second):
+		t.Fatal("item wasn't flushed by interval")
+	}
+
+	assert.NoError(t, indexer.Close(context.Background()))
+
+	// stored batch is replayed too.
+	assert.Len(t, tr.requests(), 2)
+	assert.False(t, st.IsUsed())
+
+	stats := indexer.Stats()
+	assert.Equal(t, uint64(1), stats.Added)
+	assert.Equal(t, uint64(1), stats.Flushed)
+	assert.Equal(t, uint64(1), stats.Succeeded)
+	assert.Equal(t, uint64(1), stats.BatchesReplayed)
+}
+
+// failingStorage refuses to put batches.
+type failingStorage struct {
+	test.StubStorage
+
+	err error
+}
+
+func (s *failingStorage) Put([]byte) error { return s.err }
+
+func TestBulkIndexer_hooks(t *testing.T) {
+	const action = "{\"index\":{\"_id\":\"1\",\"_index\":\"test\"}}\n{}\n"
+
+	storageErr := errors.New("storage error")
+	hooks := &recordingHooks{}
+	results := &itemResults{}
+
+	indexer := newTestIndexer(&bulkTransport{disconnected: true}, &failingStorage{err: storageErr}, 1, time.Hour)
+	indexer.writer.hooks = hooks
+
+	assert.NoError(t, indexer.Add(context.Background(), results.item("", "1", "{}")))
+	assert.Error(t, indexer.Flush(context.Background()))
+
+	assert.Equal(t, []string{fmt.Sprintf("dropped %d: storage error", len(action))}, hooks.events)
+	assert.Equal(t, map[string]error{"1": storageErr}, results.results)
+
+	stats := indexer.Stats()
+	assert.Equal(t, uint64(1), stats.Failed)
+	assert.Equal(t, uint64(1), stats.BatchesFailed)
+	assert.Equal(t, storageErr, stats.LastError)
+
+	assert.NoError(t, indexer.Close(context.Background()))
+}
+
+func TestBulkIndexer_createConflict(t *testing.T) {
+	conflict := transport.FailedItem{
+		Position: 0,
+		Action:   "create",
+		BulkItem: transport.BulkItem{
+			Index:  "test",
+			Status: http.StatusConflict,
+			Error:  &transport.BulkItemReason{Type: "version_conflict_engine_exception"},
+		},
+	}
+
+	tr := &bulkTransport{errs: []error{&transport.BulkError{Items: []transport.FailedItem{conflict}}}}
+	results := &itemResults{}
+
+	indexer := newTestIndexer(tr, &test.StubStorage{}, 1, time.Hour)
+
+	assert.NoError(t, indexer.Add(context.Background(), results.item("create", "1", "{}")))
+	assert.NoError(t, indexer.Flush(context.Background()))
+
+	// the caller's ID already exists, so the action fails.
+	assert.Equal(t, map[string]error{"1": &ActionError{FailedItem: conflict}}, results.results)
+	assert.Equal(t, uint64(1), indexer.Stats().Failed)
+
+	assert.NoError(t, indexer.Close(context.Background()))
+}
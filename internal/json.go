@@ -0,0 +1,340 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+// LookupField returns raw value of the top-level field of JSON object
+// without decoding the whole document.
+func LookupField(doc []byte, key string) (value []byte, ok bool) {
+	start, end := lookupField(doc, key)
+	if start < 0 {
+		return nil, false
+	}
+
+	return doc[start:end], true
+}
+
+// lookupField returns bounds of the field value or -1 if field is not found.
+func lookupField(doc []byte, key string) (start, end int) {
+	i := skipSpaces(doc, 0)
+	if i >= len(doc) || doc[i] != '{' {
+		return -1, -1
+	}
+
+	for i++; ; i++ {
+		i = skipSpaces(doc, i)
+		if i >= len(doc) || doc[i] != '"' {
+			return -1, -1
+		}
+
+		end = skipString(doc, i)
+		if end < 0 {
+			return -1, -1
+		}
+
+		name := doc[i+1 : end-1]
+
+		i = skipSpaces(doc, end)
+		if i >= len(doc) || doc[i] != ':' {
+			return -1, -1
+		}
+
+		i = skipSpaces(doc, i+1)
+
+		end = skipValue(doc, i)
+		if end < 0 {
+			return -1, -1
+		}
+
+		if string(name) == key {
+			return i, end
+		}
+
+		i = skipSpaces(doc, end)
+		if i >= len(doc) || doc[i] != ',' {
+			return -1, -1
+		}
+	}
+}
+
+// NextDocument returns the first document of the input and the rest of it.
+// JSON objects and arrays may span several lines, other documents end with a newline.
+// Whitespaces around documents and blank lines are skipped, nil is returned at the end of input.
+func NextDocument(p []byte) (doc, rest []byte) {
+	i := skipSpaces(p, 0)
+	if i >= len(p) {
+		return nil, nil
+	}
+
+	p = p[i:]
+
+	if p[0] == '{' || p[0] == '[' {
+		if end := skipComposite(p, 0); end > 0 {
+			return p[:end], p[end:]
+		}
+	}
+
+	if i = bytes.IndexByte(p, '\n'); i >= 0 {
+		return bytes.TrimRight(p[:i], " \t\r"), p[i+1:]
+	}
+
+	return bytes.TrimRight(p, " \t\r"), nil
+}
+
+// LookupString returns top-level field of JSON object as a string.
+// Strings are unquoted, numbers and booleans are returned as is,
+// objects, arrays and nulls are not supported.
+func LookupString(doc []byte, key string) (string, bool) {
+	value, ok := LookupField(doc, key)
+	if !ok || len(value) == 0 {
+		return "", false
+	}
+
+	switch value[0] {
+	case '{', '[', 'n':
+		return "", false
+	case '"':
+		return Unquote(value)
+	default:
+		return string(value), true
+	}
+}
+
+// Unquote returns value of the raw JSON string.
+func Unquote(value []byte) (s string, ok bool) {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return "", false
+	}
+
+	if !strings.ContainsRune(string(value), '\\') {
+		return string(value[1 : len(value)-1]), true
+	}
+
+	if err := json.Unmarshal(value, &s); err != nil {
+		return "", false
+	}
+
+	return s, true
+}
+
+// AppendQuoted appends s to dst as a JSON string.
+func AppendQuoted(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c == '"' || c == '\\' || c >= utf8.RuneSelf {
+			value, _ := json.Marshal(s)
+
+			return append(dst, value...)
+		}
+	}
+
+	dst = append(dst, '"')
+	dst = append(dst, s...)
+
+	return append(dst, '"')
+}
+
+// AppendQuotedBytes is like AppendQuoted, but for byte slices.
+func AppendQuotedBytes(dst, s []byte) []byte {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c == '"' || c == '\\' || c >= utf8.RuneSelf {
+			value, _ := json.Marshal(string(s))
+
+			return append(dst, value...)
+		}
+	}
+
+	dst = append(dst, '"')
+	dst = append(dst, s...)
+
+	return append(dst, '"')
+}
+
+func skipSpaces(doc []byte, i int) int {
+	for i < len(doc) {
+		switch doc[i] {
+		case ' ', '\t', '\r', '\n':
+			i++
+		default:
+			return i
+		}
+	}
+
+	return i
+}
+
+// skipString returns position after the closing quote or -1 if string is not closed.
+func skipString(doc []byte, i int) int {
+	for i++; i < len(doc); i++ {
+		switch doc[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+
+	return -1
+}
+
+// skipValue returns position after the value or -1 if value is malformed.
+func skipValue(doc []byte, i int) int {
+	if i >= len(doc) {
+		return -1
+	}
+
+	switch doc[i] {
+	case '"':
+		return skipString(doc, i)
+	case '{', '[':
+		return skipComposite(doc, i)
+	}
+
+	start := i
+
+	for i < len(doc) {
+		switch doc[i] {
+		case ',', '}', ']', ' ', '\t', '\r', '\n':
+			if i == start {
+				return -1
+			}
+
+			return i
+		}
+
+		i++
+	}
+
+	return i
+}
+
+func skipComposite(doc []byte, i int) int {
+	var depth int
+
+	for i < len(doc) {
+		switch doc[i] {
+		case '"':
+			if i = skipString(doc, i); i < 0 {
+				return -1
+			}
+
+			continue
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth--; depth == 0 {
+				return i + 1
+			}
+		}
+
+		i++
+	}
+
+	return -1
+}
+
+// TruncateString returns the document with top-level string field shortened and
+// ended with suffix, so the document fits into size bytes. Returns false if field
+// is not a string or can't be shortened enough.
+func TruncateString(doc []byte, key string, size int, suffix string) ([]byte, bool) {
+	if len(doc) <= size {
+		return doc, true
+	}
+
+	start, end := lookupField(doc, key)
+	if start < 0 || doc[start] != '"' {
+		return nil, false
+	}
+
+	s, ok := Unquote(doc[start:end])
+	if !ok {
+		return nil, false
+	}
+
+	budget := size - len(doc) + end - start
+
+	// escaped value is never shorter than unquoted one.
+	n := budget - len(suffix) - 2
+	if n > len(s) {
+		n = len(s)
+	}
+
+	for n >= 0 {
+		for n > 0 && n < len(s) && !utf8.RuneStart(s[n]) {
+			n--
+		}
+
+		value, err := json.Marshal(s[:n] + suffix)
+		if err != nil {
+			return nil, false
+		}
+
+		if len(value) <= budget {
+			res := make([]byte, 0, len(doc)-end+start+len(value))
+			res = append(res, doc[:start]...)
+			res = append(res, value...)
+
+			return append(res, doc[end:]...), true
+		}
+
+		n -= len(value) - budget
+	}
+
+	return nil, false
+}
+
+// InsertField appends doc to dst with key and raw JSON value inserted as the first field.
+// Documents which are not JSON objects are appended as is.
+func InsertField(dst, doc []byte, key string, value []byte) []byte {
+	i := skipSpaces(doc, 0)
+	if i >= len(doc) || doc[i] != '{' {
+		return append(dst, doc...)
+	}
+
+	dst = append(dst, doc[:i+1]...)
+	dst = append(dst, '"')
+	dst = append(dst, key...)
+	dst = append(dst, '"', ':')
+	dst = append(dst, value...)
+
+	if j := skipSpaces(doc, i+1); j < len(doc) && doc[j] != '}' {
+		dst = append(dst, ',')
+	}
+
+	return append(dst, doc[i+1:]...)
+}
+
+// InsertFields appends doc to dst with raw JSON fields, e.g. "a":1,"b":2, inserted first.
+// Documents which are not JSON objects are appended as is.
+func InsertFields(dst, doc, fields []byte) []byte {
+	i := skipSpaces(doc, 0)
+	if i >= len(doc) || doc[i] != '{' || len(fields) == 0 {
+		return append(dst, doc...)
+	}
+
+	dst = append(dst, doc[:i+1]...)
+	dst = append(dst, fields...)
+
+	if j := skipSpaces(doc, i+1); j < len(doc) && doc[j] != '}' {
+		dst = append(dst, ',')
+	}
+
+	return append(dst, doc[i+1:]...)
+}
+
+// ReplaceField appends doc to dst with raw JSON value of the top-level field replaced.
+// Returns false if the field is not found.
+func ReplaceField(dst, doc []byte, key string, value []byte) ([]byte, bool) {
+	start, end := lookupField(doc, key)
+	if start < 0 {
+		return dst, false
+	}
+
+	dst = append(dst, doc[:start]...)
+	dst = append(dst, value...)
+
+	return append(dst, doc[end:]...), true
+}
@@ -0,0 +1,205 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupField(t *testing.T) {
+	doc := []byte(` { "a" : "x\"}" , "obj":{"b":[1,{"c":"]"}]}, "num":-1.5e3,"t":true, "n":null,"s":"val"} `)
+
+	tests := []struct {
+		name     string
+		doc      []byte
+		key      string
+		expected string
+		ok       bool
+	}{
+		{name: "EscapedString", doc: doc, key: "a", expected: `"x\"}"`, ok: true},
+		{name: "Object", doc: doc, key: "obj", expected: `{"b":[1,{"c":"]"}]}`, ok: true},
+		{name: "Number", doc: doc, key: "num", expected: `-1.5e3`, ok: true},
+		{name: "Bool", doc: doc, key: "t", expected: `true`, ok: true},
+		{name: "Null", doc: doc, key: "n", expected: `null`, ok: true},
+		{name: "Last", doc: doc, key: "s", expected: `"val"`, ok: true},
+		{name: "NestedNotFound", doc: doc, key: "b", ok: false},
+		{name: "Missing", doc: doc, key: "missing", ok: false},
+		{name: "NotObject", doc: []byte(`["a"]`), key: "a", ok: false},
+		{name: "Malformed", doc: []byte(`{"x":"unclosed, "a":"b"}`), key: "a", ok: false},
+		{name: "Empty", doc: nil, key: "a", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := LookupField(tt.doc, tt.key)
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, string(value))
+		})
+	}
+}
+
+func TestLookupString(t *testing.T) {
+	doc := []byte(`{"s":"abc","n":10,"o":{},"null":null}`)
+
+	tests := []struct {
+		key      string
+		expected string
+		ok       bool
+	}{
+		{key: "s", expected: "abc", ok: true},
+		{key: "n", expected: "10", ok: true},
+		{key: "o", ok: false},
+		{key: "null", ok: false},
+		{key: "missing", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			value, ok := LookupString(doc, tt.key)
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestAppendQuoted(t *testing.T) {
+	assert.Equal(t, `x"abc"`, string(AppendQuoted([]byte("x"), "abc")))
+	assert.Equal(t, `"a\"b\n"`, string(AppendQuoted(nil, "a\"b\n")))
+	assert.Equal(t, `x"abc"`, string(AppendQuotedBytes([]byte("x"), []byte("abc"))))
+	assert.Equal(t, `"a\\b"`, string(AppendQuotedBytes(nil, []byte(`a\b`))))
+}
+
+func TestInsertField(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		expected string
+	}{
+		{name: "Object", doc: ` {"a":1}`, expected: ` {"k":"v","a":1}`},
+		{name: "EmptyObject", doc: `{ }`, expected: `{"k":"v" }`},
+		{name: "NotObject", doc: `message`, expected: `message`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := InsertField([]byte("prefix"), []byte(tt.doc), "k", []byte(`"v"`))
+
+			assert.Equal(t, "prefix"+tt.expected, string(res))
+		})
+	}
+}
+
+func TestNextDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "Single",
+			input:    `{"a":1}`,
+			expected: []string{`{"a":1}`},
+		},
+		{
+			name:     "NewlineDelimited",
+			input:    "{\"a\":1}\n\n  {\"b\":\"x\\ny\"}\r\n",
+			expected: []string{`{"a":1}`, `{"b":"x\ny"}`},
+		},
+		{
+			name:     "PrettyPrinted",
+			input:    "{\n  \"a\": {\n    \"b\": \"}\"\n  }\n}\n{\"c\":3}",
+			expected: []string{"{\n  \"a\": {\n    \"b\": \"}\"\n  }\n}", `{"c":3}`},
+		},
+		{
+			name:     "PlainText",
+			input:    "first line \nsecond line",
+			expected: []string{"first line", "second line"},
+		},
+		{
+			name:     "Unclosed",
+			input:    "{\"a\":1\n{\"b\":2}",
+			expected: []string{`{"a":1`, `{"b":2}`},
+		},
+		{
+			name:     "Blank",
+			input:    " \n\t\n",
+			expected: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var res []string
+
+			for doc, rest := NextDocument([]byte(tt.input)); doc != nil; doc, rest = NextDocument(rest) {
+				res = append(res, string(doc))
+			}
+
+			assert.Equal(t, tt.expected, res)
+		})
+	}
+}
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		size     int
+		expected string
+		ok       bool
+	}{
+		{name: "Fits", doc: `{"msg":"abc"}`, size: 20, expected: `{"msg":"abc"}`, ok: true},
+		{name: "Truncated", doc: `{"msg":"abcdefghij","n":1}`, size: 22, expected: `{"msg":"abc...","n":1}`, ok: true},
+		{name: "Escaped", doc: `{"msg":"a\"b\"c\"d"}`, size: 17, expected: `{"msg":"a\"..."}`, ok: true},
+		{
+			name:     "EscapesLongerThanValue",
+			doc:      `{"msg":"` + strings.Repeat(`\n`, 100) + `"}`,
+			size:     160,
+			expected: `{"msg":"` + strings.Repeat(`\n`, 47) + `..."}`,
+			ok:       true,
+		},
+		{name: "Runes", doc: `{"msg":"абвгд"}`, size: 18, expected: `{"msg":"аб..."}`, ok: true},
+		{name: "TooSmall", doc: `{"msg":"abcdefghij","n":1}`, size: 10, ok: false},
+		{name: "NotString", doc: `{"msg":{"a":"abcdefghij"}}`, size: 10, ok: false},
+		{name: "Missing", doc: `{"a":"abcdefghij"}`, size: 10, ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, ok := TruncateString([]byte(tt.doc), "msg", tt.size, "...")
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, string(res))
+			assert.True(t, len(res) <= tt.size)
+		})
+	}
+}
+
+func TestInsertFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		fields   string
+		expected string
+	}{
+		{name: "Object", doc: `{"a":1}`, fields: `"b":2,"c":3`, expected: `{"b":2,"c":3,"a":1}`},
+		{name: "EmptyObject", doc: `{}`, fields: `"b":2`, expected: `{"b":2}`},
+		{name: "NoFields", doc: `{"a":1}`, fields: ``, expected: `{"a":1}`},
+		{name: "NotObject", doc: `message`, fields: `"b":2`, expected: `message`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, string(InsertFields(nil, []byte(tt.doc), []byte(tt.fields))))
+		})
+	}
+}
+
+func TestReplaceField(t *testing.T) {
+	res, ok := ReplaceField(nil, []byte(`{"a":{"x":1},"b":2}`), "a", []byte(`"v"`))
+
+	assert.True(t, ok)
+	assert.Equal(t, `{"a":"v","b":2}`, string(res))
+
+	res, ok = ReplaceField(nil, []byte(`{"b":2}`), "a", []byte(`"v"`))
+
+	assert.False(t, ok)
+	assert.Empty(t, res)
+}
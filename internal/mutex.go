@@ -0,0 +1,28 @@
+package internal
+
+import "context"
+
+// Mutex is a mutual exclusion lock, which can be acquired with deadline.
+type Mutex chan struct{}
+
+func NewMutex() Mutex {
+	return make(Mutex, 1)
+}
+
+func (m Mutex) Lock() {
+	m <- struct{}{}
+}
+
+// LockContext acquires the lock or returns context error.
+func (m Mutex) LockContext(ctx context.Context) error {
+	select {
+	case m <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m Mutex) Unlock() {
+	<-m
+}
@@ -0,0 +1,26 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutex(t *testing.T) {
+	mu := NewMutex()
+
+	mu.Lock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, mu.LockContext(ctx))
+
+	mu.Unlock()
+
+	assert.Nil(t, mu.LockContext(context.Background()))
+
+	mu.Unlock()
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter allows one event of each kind per interval.
+// Nil limiter allows all events.
+type RateLimiter struct {
+	interval time.Duration
+
+	mu    sync.Mutex
+	kinds map[string]*rateState
+}
+
+type rateState struct {
+	last       time.Time
+	suppressed int
+}
+
+func NewRateLimiter(interval time.Duration) *RateLimiter {
+	return &RateLimiter{
+		interval: interval,
+		kinds:    make(map[string]*rateState),
+	}
+}
+
+// Allow reports whether the event of the kind is allowed and returns
+// a number of events of the kind suppressed since the last allowed one.
+func (l *RateLimiter) Allow(kind string) (ok bool, suppressed int) {
+	if l == nil {
+		return true, 0
+	}
+
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state, ok := l.kinds[kind]
+	if !ok {
+		l.kinds[kind] = &rateState{last: now}
+
+		return true, 0
+	}
+
+	if now.Sub(state.last) < l.interval {
+		state.suppressed++
+
+		return false, 0
+	}
+
+	suppressed = state.suppressed
+
+	state.last = now
+	state.suppressed = 0
+
+	return true, suppressed
+}
@@ -0,0 +1,36 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	limiter := NewRateLimiter(50 * time.Millisecond)
+
+	ok, suppressed := limiter.Allow("a")
+	assert.True(t, ok)
+	assert.Equal(t, 0, suppressed)
+
+	ok, _ = limiter.Allow("a")
+	assert.False(t, ok)
+
+	ok, _ = limiter.Allow("a")
+	assert.False(t, ok)
+
+	ok, _ = limiter.Allow("b")
+	assert.True(t, ok)
+
+	time.Sleep(60 * time.Millisecond)
+
+	ok, suppressed = limiter.Allow("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, suppressed)
+
+	var nilLimiter *RateLimiter
+
+	ok, _ = nilLimiter.Allow("a")
+	assert.True(t, ok)
+}
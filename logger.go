@@ -1,5 +1,115 @@
 package elw
 
+import (
+	"github.com/gadavy/elw/internal"
+	"github.com/gadavy/elw/transport"
+)
+
 type Logger interface {
 	Printf(format string, v ...interface{})
 }
+
+// LeveledLogger is used instead of Printf if the logger implements it.
+// Debug messages are written only to leveled loggers.
+type LeveledLogger interface {
+	Logger
+	Debugf(format string, v ...interface{})
+	Infof(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+// logf writes message to the logger if the limiter allows message of the same format.
+func logf(logger Logger, limiter *internal.RateLimiter, level logLevel, format string, v ...interface{}) {
+	logKindf(logger, limiter, level, format, format, v...)
+}
+
+// logKindf is like logf, but messages are limited by the kind instead of the format,
+// e.g. messages of different nodes are limited separately.
+func logKindf(logger Logger, limiter *internal.RateLimiter, level logLevel, kind, format string, v ...interface{}) {
+	if logger == nil {
+		return
+	}
+
+	ok, suppressed := limiter.Allow(kind)
+	if !ok {
+		return
+	}
+
+	if suppressed > 0 {
+		format += " (%d similar messages suppressed)"
+		v = append(v, suppressed)
+	}
+
+	leveled, ok := logger.(LeveledLogger)
+	if !ok {
+		if level > levelDebug {
+			logger.Printf(format, v...)
+		}
+
+		return
+	}
+
+	switch level {
+	case levelDebug:
+		leveled.Debugf(format, v...)
+	case levelInfo:
+		leveled.Infof(format, v...)
+	case levelWarn:
+		leveled.Warnf(format, v...)
+	default:
+		leveled.Errorf(format, v...)
+	}
+}
+
+func (w *ElasticWriter) logf(level logLevel, format string, v ...interface{}) {
+	logf(w.logger, w.logLimiter, level, format, v...)
+}
+
+// nodeLogger logs events of nodes and passes them to hooks.
+type nodeLogger struct {
+	hooks   transport.Hooks
+	logger  Logger
+	limiter *internal.RateLimiter
+}
+
+func (l *nodeLogger) OnNodeDown(host string, err error) {
+	logKindf(l.logger, l.limiter, levelWarn, "down "+host, "node %s marked dead: %v", host, err)
+
+	if l.hooks != nil {
+		l.hooks.OnNodeDown(host, err)
+	}
+}
+
+func (l *nodeLogger) OnNodeUp(host string) {
+	logKindf(l.logger, l.limiter, levelInfo, "up "+host, "node %s is live", host)
+
+	if l.hooks != nil {
+		l.hooks.OnNodeUp(host)
+	}
+}
+
+func (l *nodeLogger) OnDisconnected() {
+	logf(l.logger, l.limiter, levelError, "all nodes are dead, batches are put to storage")
+
+	if l.hooks != nil {
+		l.hooks.OnDisconnected()
+	}
+}
+
+func (l *nodeLogger) OnReconnected() {
+	logf(l.logger, l.limiter, levelInfo, "connection restored, replaying storage")
+
+	if l.hooks != nil {
+		l.hooks.OnReconnected()
+	}
+}
@@ -0,0 +1,114 @@
+package elw
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gadavy/elw/internal"
+)
+
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+type recordingLeveledLogger struct {
+	recordingLogger
+}
+
+func (l *recordingLeveledLogger) Debugf(format string, v ...interface{}) {
+	l.Printf("debug: "+format, v...)
+}
+func (l *recordingLeveledLogger) Infof(format string, v ...interface{}) {
+	l.Printf("info: "+format, v...)
+}
+func (l *recordingLeveledLogger) Warnf(format string, v ...interface{}) {
+	l.Printf("warn: "+format, v...)
+}
+func (l *recordingLeveledLogger) Errorf(format string, v ...interface{}) {
+	l.Printf("error: "+format, v...)
+}
+
+func TestLogf(t *testing.T) {
+	t.Run("Printf", func(t *testing.T) {
+		logger := &recordingLogger{}
+
+		logf(logger, nil, levelDebug, "debug %d", 1)
+		logf(logger, nil, levelError, "error %d", 2)
+
+		assert.Equal(t, []string{"error 2"}, logger.messages)
+	})
+
+	t.Run("Leveled", func(t *testing.T) {
+		logger := &recordingLeveledLogger{}
+
+		logf(logger, nil, levelDebug, "message")
+		logf(logger, nil, levelInfo, "message")
+		logf(logger, nil, levelWarn, "message")
+		logf(logger, nil, levelError, "message")
+
+		assert.Equal(t, []string{"debug: message", "info: message", "warn: message", "error: message"}, logger.messages)
+	})
+
+	t.Run("RateLimit", func(t *testing.T) {
+		logger := &recordingLogger{}
+		limiter := internal.NewRateLimiter(50 * time.Millisecond)
+
+		for i := 0; i < 3; i++ {
+			logf(logger, limiter, levelError, "error %d", i)
+		}
+
+		time.Sleep(60 * time.Millisecond)
+
+		logf(logger, limiter, levelError, "error %d", 3)
+
+		assert.Equal(t, []string{"error 0", "error 3 (2 similar messages suppressed)"}, logger.messages)
+	})
+}
+
+func TestNodeLogger(t *testing.T) {
+	logger := &recordingLogger{}
+	hooks := &recordingHooks{}
+
+	l := &nodeLogger{hooks: hooks, logger: logger}
+
+	l.OnNodeDown("http://node", errors.New("timeout"))
+	l.OnDisconnected()
+	l.OnNodeUp("http://node")
+	l.OnReconnected()
+
+	assert.Equal(t, []string{
+		"node http://node marked dead: timeout",
+		"all nodes are dead, batches are put to storage",
+		"node http://node is live",
+		"connection restored, replaying storage",
+	}, logger.messages)
+
+	assert.Equal(t, []string{"down http://node: timeout", "disconnected", "up http://node", "reconnected"}, hooks.events)
+}
+
+func TestNodeLogger_RateLimit(t *testing.T) {
+	logger := &recordingLogger{}
+
+	l := &nodeLogger{logger: logger, limiter: internal.NewRateLimiter(time.Minute)}
+
+	l.OnNodeDown("http://node-a", errors.New("timeout"))
+	l.OnNodeDown("http://node-b", errors.New("timeout"))
+	l.OnNodeDown("http://node-a", errors.New("timeout"))
+	l.OnNodeUp("http://node-a")
+	l.OnNodeUp("http://node-b")
+
+	assert.Equal(t, []string{
+		"node http://node-a marked dead: timeout",
+		"node http://node-b marked dead: timeout",
+		"node http://node-a is live",
+		"node http://node-b is live",
+	}, logger.messages)
+}
@@ -0,0 +1,13 @@
+module github.com/gadavy/elw/logrusx
+
+go 1.12
+
+require (
+	github.com/gadavy/elw v0.0.0-20261018091917-6dd803b0c9ad
+	github.com/sirupsen/logrus v1.6.0
+	github.com/stretchr/objx v0.1.1 // indirect
+	github.com/stretchr/testify v1.4.0
+	golang.org/x/sys v0.0.0-20191026070338-33540a1f6037 // indirect
+)
+
+replace github.com/gadavy/elw => ../
@@ -0,0 +1,203 @@
+// Package logrusx is a logrus hook writing entries to Elasticsearch via elw.ElasticWriter.
+//
+// Documents are built straight from entries without a formatter, with ECS-style
+// field names: @timestamp, log.level, message, log.origin.* and error.* fields.
+// Output of the logger may be discarded:
+//
+//	writer, err := elw.NewElasticWriter(elw.Config{TimestampFields: []string{"@timestamp"}})
+//	hook, err := logrusx.NewHook(logrusx.Config{Writer: writer})
+//	log := logrus.New()
+//	log.SetOutput(ioutil.Discard)
+//	log.AddHook(hook)
+package logrusx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/gadavy/elw"
+)
+
+// fieldsPrefix is added to keys of entry fields clashing with ECS fields.
+const fieldsPrefix = "fields."
+
+var reservedKeys = map[string]bool{
+	elw.TimestampKey:    true,
+	elw.LevelKey:        true,
+	elw.MessageKey:      true,
+	elw.ErrorMessageKey: true,
+	elw.ErrorTypeKey:    true,
+	elw.FileNameKey:     true,
+	elw.FileLineKey:     true,
+	elw.FunctionKey:     true,
+}
+
+// ErrNoWriter is returned by NewHook if Config.Writer is nil.
+var ErrNoWriter = errors.New("logrusx: writer is required")
+
+// Writer receives documents of fired entries, see elw.SyncWriter.
+type Writer = elw.SyncWriter
+
+type Config struct {
+	// Writer receives entries of all levels except LevelWriters ones.
+	Writer       Writer
+	LevelWriters map[logrus.Level]Writer
+
+	// Levels are levels of entries fired to the hook, logrus.AllLevels by default.
+	Levels []logrus.Level
+
+	// SyncTimeout limits waiting for delivery on Sync, unlimited if zero.
+	SyncTimeout time.Duration
+}
+
+// Hook is a logrus.Hook writing JSON documents to elw writers.
+// Writers are synced on panic and fatal entries and by logrus.Exit.
+type Hook struct {
+	writers *elw.LevelWriters
+	fired   []logrus.Level
+
+	bufPool sync.Pool
+}
+
+func NewHook(cfg Config) (*Hook, error) {
+	if cfg.Writer == nil {
+		return nil, ErrNoWriter
+	}
+
+	if len(cfg.Levels) == 0 {
+		cfg.Levels = logrus.AllLevels
+	}
+
+	writers := elw.NewLevelWriters(cfg.Writer, cfg.SyncTimeout)
+
+	for level, w := range cfg.LevelWriters {
+		writers.Set(int(level), w)
+	}
+
+	h := &Hook{
+		writers: writers,
+		fired:   cfg.Levels,
+		bufPool: sync.Pool{New: func() interface{} {
+			b := make([]byte, 0, 1024)
+
+			return &b
+		}},
+	}
+
+	logrus.RegisterExitHandler(func() {
+		_ = h.Sync()
+	})
+
+	return h, nil
+}
+
+func (h *Hook) Levels() []logrus.Level {
+	return h.fired
+}
+
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	bp := h.bufPool.Get().(*[]byte)
+	*bp = appendEntry((*bp)[:0], entry)
+
+	_, err := h.writers.Writer(int(entry.Level)).Write(*bp)
+	h.bufPool.Put(bp)
+
+	if err != nil {
+		return err
+	}
+
+	// panic entries are followed by panic, fatal ones are synced by the exit handler too.
+	if entry.Level <= logrus.FatalLevel {
+		return h.Sync()
+	}
+
+	return nil
+}
+
+// Sync waits until fired entries are delivered to Elasticsearch or put to the storage
+// of every writer and returns the first error.
+func (h *Hook) Sync() error {
+	return h.writers.Sync()
+}
+
+// appendEntry appends JSON document of the entry to buf. Fields are sorted and prefixed with
+// "fields." if clash with ECS fields, logrus.ErrorKey error is written as error.message and error.type.
+func appendEntry(buf []byte, entry *logrus.Entry) []byte {
+	buf = append(buf, '{')
+	buf = appendKey(buf, elw.TimestampKey)
+	buf = elw.AppendQuoted(buf, entry.Time.Format(time.RFC3339Nano))
+	buf = append(buf, ',')
+	buf = appendKey(buf, elw.LevelKey)
+	buf = elw.AppendQuoted(buf, entry.Level.String())
+	buf = append(buf, ',')
+	buf = appendKey(buf, elw.MessageKey)
+	buf = elw.AppendQuoted(buf, entry.Message)
+
+	if entry.HasCaller() {
+		buf = append(buf, ',')
+		buf = appendKey(buf, elw.FileNameKey)
+		buf = elw.AppendQuoted(buf, entry.Caller.File)
+		buf = append(buf, ',')
+		buf = appendKey(buf, elw.FileLineKey)
+		buf = strconv.AppendInt(buf, int64(entry.Caller.Line), 10)
+		buf = append(buf, ',')
+		buf = appendKey(buf, elw.FunctionKey)
+		buf = elw.AppendQuoted(buf, entry.Caller.Function)
+	}
+
+	keys := make([]string, 0, len(entry.Data))
+
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := entry.Data[key]
+
+		if err, ok := value.(error); ok {
+			if key == logrus.ErrorKey {
+				buf = append(buf, ',')
+				buf = appendKey(buf, elw.ErrorMessageKey)
+				buf = elw.AppendQuoted(buf, err.Error())
+				buf = append(buf, ',')
+				buf = appendKey(buf, elw.ErrorTypeKey)
+				buf = elw.AppendQuoted(buf, fmt.Sprintf("%T", err))
+
+				continue
+			}
+
+			value = err.Error()
+		}
+
+		// unsupported values are written as strings, so the entry isn't lost.
+		raw, err := json.Marshal(value)
+		if err != nil {
+			raw, _ = json.Marshal(fmt.Sprint(value))
+		}
+
+		if reservedKeys[key] {
+			key = fieldsPrefix + key
+		}
+
+		buf = append(buf, ',')
+		buf = appendKey(buf, key)
+		buf = append(buf, raw...)
+	}
+
+	return append(buf, '}')
+}
+
+func appendKey(buf []byte, key string) []byte {
+	buf = elw.AppendQuoted(buf, key)
+
+	return append(buf, ':')
+}
@@ -0,0 +1,127 @@
+package logrusx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gadavy/elw"
+)
+
+var _ Writer = (*elw.ElasticWriter)(nil)
+
+type recordingWriter struct {
+	docs  []map[string]interface{}
+	syncs int
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	var doc map[string]interface{}
+
+	if err := json.Unmarshal(p, &doc); err != nil {
+		return 0, err
+	}
+
+	w.docs = append(w.docs, doc)
+
+	return len(p), nil
+}
+
+func (w *recordingWriter) SyncContext(ctx context.Context) error {
+	w.syncs++
+
+	return nil
+}
+
+func TestNewHook(t *testing.T) {
+	_, err := NewHook(Config{})
+
+	assert.Equal(t, ErrNoWriter, err)
+}
+
+func TestHook_Fire(t *testing.T) {
+	writer := &recordingWriter{}
+
+	hook, err := NewHook(Config{Writer: writer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	log.SetReportCaller(true)
+	log.AddHook(hook)
+
+	log.WithError(os.ErrNotExist).WithFields(logrus.Fields{
+		"count":   2,
+		"message": "clash",
+		"cause":   errors.New("x"),
+		"channel": make(chan int),
+	}).Warn("test message")
+
+	if !assert.Len(t, writer.docs, 1) {
+		return
+	}
+
+	doc := writer.docs[0]
+
+	assert.Contains(t, doc, elw.TimestampKey)
+	assert.Equal(t, "warning", doc[elw.LevelKey])
+	assert.Equal(t, "test message", doc[elw.MessageKey])
+	assert.Equal(t, "clash", doc["fields.message"])
+	assert.Equal(t, float64(2), doc["count"])
+	assert.Equal(t, "x", doc["cause"])
+	assert.Contains(t, doc["channel"], "0x")
+	assert.Equal(t, os.ErrNotExist.Error(), doc[elw.ErrorMessageKey])
+	assert.Equal(t, "*errors.errorString", doc[elw.ErrorTypeKey])
+	assert.Contains(t, doc[elw.FileNameKey], "hook_test.go")
+	assert.Contains(t, doc, elw.FileLineKey)
+	assert.Contains(t, doc[elw.FunctionKey], "TestHook_Fire")
+}
+
+func TestHook_LevelWriters(t *testing.T) {
+	writer, errWriter := &recordingWriter{}, &recordingWriter{}
+
+	hook, err := NewHook(Config{
+		Writer:       writer,
+		LevelWriters: map[logrus.Level]Writer{logrus.ErrorLevel: errWriter, logrus.PanicLevel: errWriter},
+		Levels:       []logrus.Level{logrus.PanicLevel, logrus.ErrorLevel, logrus.InfoLevel},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := logrus.New()
+	log.SetOutput(ioutil.Discard)
+	log.AddHook(hook)
+
+	log.Info("info")
+	log.Warn("skipped")
+	log.Error("error")
+
+	assert.Len(t, writer.docs, 1)
+	assert.Len(t, errWriter.docs, 1)
+
+	assert.Panics(t, func() { log.Panic("panic") })
+	assert.Len(t, errWriter.docs, 2)
+	assert.Equal(t, 1, writer.syncs)
+	assert.Equal(t, 1, errWriter.syncs)
+}
+
+func TestAppendEntry(t *testing.T) {
+	buf := appendEntry(nil, &logrus.Entry{
+		Time:    time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:   logrus.InfoLevel,
+		Message: "line\n\"quoted\"",
+		Data:    logrus.Fields{"b": true, "a": "x"},
+	})
+
+	assert.Equal(t, `{"@timestamp":"2020-01-02T03:04:05Z","log.level":"info","message":"line\n\"quoted\"","a":"x","b":true}`, string(buf))
+}
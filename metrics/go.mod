@@ -0,0 +1,11 @@
+module github.com/gadavy/elw/metrics
+
+go 1.12
+
+require (
+	github.com/gadavy/elw v0.0.0-20261018091917-6dd803b0c9ad
+	github.com/prometheus/client_golang v1.7.1
+	github.com/stretchr/testify v1.4.0
+)
+
+replace github.com/gadavy/elw => ../
@@ -0,0 +1,253 @@
+// Package metrics exports statistics of the writer and its bulk requests
+// as Prometheus metrics and expvar variables.
+package metrics
+
+import (
+	"expvar"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/gadavy/elw"
+	"github.com/gadavy/elw/transport"
+)
+
+// DefaultNamespace is a namespace of metric names.
+const DefaultNamespace = "elw"
+
+// statusError is a code label of requests failed without response.
+const statusError = "error"
+
+// Collector is a prometheus.Collector of the writer metrics.
+// Bulk metrics are collected by ObserveBulk, which must be set as Config.OnBulk:
+//
+//	collector := metrics.New(metrics.DefaultNamespace)
+//	writer, err := elw.NewElasticWriter(elw.Config{OnBulk: collector.ObserveBulk})
+//	collector.Watch(writer)
+//	prometheus.MustRegister(collector)
+type Collector struct {
+	writer atomic.Value
+
+	bulkDuration *prometheus.HistogramVec
+	bulkRequests *prometheus.CounterVec
+	batchSize    prometheus.Histogram
+
+	documents       *prometheus.Desc
+	bytes           *prometheus.Desc
+	rejected        *prometheus.Desc
+	batches         *prometheus.Desc
+	queue           *prometheus.Desc
+	inFlight        *prometheus.Desc
+	storageBatches  *prometheus.Desc
+	storageBytes    *prometheus.Desc
+	storageAge      *prometheus.Desc
+	connected       *prometheus.Desc
+	nodeUp          *prometheus.Desc
+	lastErrorMetric *prometheus.Desc
+
+	mu    sync.Mutex
+	nodes map[string]*nodeStats
+}
+
+// nodeStats are bulk requests of the node published via expvar.
+type nodeStats struct {
+	Requests        map[string]uint64 `json:"requests"`
+	DurationSeconds float64           `json:"duration_seconds"`
+	Bytes           uint64            `json:"bytes"`
+}
+
+// New returns collector with metric names prefixed with the namespace.
+func New(namespace string) *Collector {
+	return &Collector{
+		bulkDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "bulk_duration_seconds",
+			Help:      "Duration of bulk requests by node.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"node"}),
+		bulkRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "bulk_requests_total",
+			Help:      "Number of bulk requests by node and response code.",
+		}, []string{"node", "code"}),
+		batchSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "bulk_request_bytes",
+			Help:      "Size of bulk request bodies.",
+			Buckets:   prometheus.ExponentialBuckets(1024, 4, 8),
+		}),
+
+		documents:       newDesc(namespace, "documents_total", "Number of documents written to batches."),
+		bytes:           newDesc(namespace, "documents_bytes_total", "Size of documents written to batches."),
+		rejected:        newDesc(namespace, "documents_rejected_total", "Number of rejected documents."),
+		batches:         newDesc(namespace, "batches_total", "Number of batches by outcome.", "outcome"),
+		queue:           newDesc(namespace, "queue_batches_total", "Number of full batches by queue outcome.", "outcome"),
+		inFlight:        newDesc(namespace, "batches_in_flight", "Number of batches queued or being sent."),
+		storageBatches:  newDesc(namespace, "storage_batches", "Number of batches kept by the storage."),
+		storageBytes:    newDesc(namespace, "storage_bytes", "Size of batches kept by the storage."),
+		storageAge:      newDesc(namespace, "storage_age_seconds", "Age of the oldest batch kept by the storage."),
+		connected:       newDesc(namespace, "connected", "Whether there are live nodes."),
+		nodeUp:          newDesc(namespace, "node_up", "Whether the node is live.", "node"),
+		lastErrorMetric: newDesc(namespace, "last_error_timestamp_seconds", "Time of the last error of sending or storing batches."),
+
+		nodes: make(map[string]*nodeStats),
+	}
+}
+
+func newDesc(namespace, name, help string, labels ...string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, labels, nil)
+}
+
+// Watch sets the writer, which statistics are collected.
+func (c *Collector) Watch(w *elw.ElasticWriter) {
+	c.writer.Store(w)
+}
+
+// ObserveBulk collects the bulk request, it's transport.BulkObserver.
+func (c *Collector) ObserveBulk(r transport.BulkResult) {
+	code := statusError
+	if r.Err == nil {
+		code = strconv.Itoa(r.StatusCode)
+	}
+
+	c.bulkDuration.WithLabelValues(r.Node).Observe(r.Duration.Seconds())
+	c.bulkRequests.WithLabelValues(r.Node, code).Inc()
+	c.batchSize.Observe(float64(r.Size))
+
+	c.mu.Lock()
+
+	node, ok := c.nodes[r.Node]
+	if !ok {
+		node = &nodeStats{Requests: make(map[string]uint64)}
+		c.nodes[r.Node] = node
+	}
+
+	node.Requests[code]++
+	node.DurationSeconds += r.Duration.Seconds()
+	node.Bytes += uint64(r.Size)
+
+	c.mu.Unlock()
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.bulkDuration.Describe(ch)
+	c.bulkRequests.Describe(ch)
+	c.batchSize.Describe(ch)
+
+	for _, desc := range []*prometheus.Desc{
+		c.documents, c.bytes, c.rejected, c.batches, c.queue, c.inFlight,
+		c.storageBatches, c.storageBytes, c.storageAge, c.connected, c.nodeUp, c.lastErrorMetric,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.bulkDuration.Collect(ch)
+	c.bulkRequests.Collect(ch)
+	c.batchSize.Collect(ch)
+
+	w, ok := c.writer.Load().(*elw.ElasticWriter)
+	if !ok {
+		return
+	}
+
+	stats := w.Stats()
+
+	counter := func(desc *prometheus.Desc, value uint64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(value), labels...)
+	}
+
+	gauge := func(desc *prometheus.Desc, value float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+	}
+
+	counter(c.documents, stats.Documents)
+	counter(c.bytes, stats.Bytes)
+	counter(c.rejected, stats.Rejected)
+	counter(c.batches, stats.BatchesSent, "sent")
+	counter(c.batches, stats.BatchesFailed, "failed")
+	counter(c.batches, stats.BatchesReplayed, "replayed")
+	counter(c.queue, stats.Queued, "queued")
+	counter(c.queue, stats.Blocked, "blocked")
+	counter(c.queue, stats.Spilled, "spilled")
+	counter(c.queue, stats.DroppedNewest, "dropped_newest")
+	counter(c.queue, stats.DroppedOldest, "dropped_oldest")
+
+	gauge(c.inFlight, float64(stats.InFlight))
+	gauge(c.storageBatches, float64(stats.StorageBatches))
+	gauge(c.storageBytes, float64(stats.StorageBytes))
+	gauge(c.storageAge, stats.StorageAge.Seconds())
+	gauge(c.connected, boolValue(stats.Connected))
+
+	for _, node := range stats.Nodes {
+		gauge(c.nodeUp, boolValue(node.Live), node.Host)
+	}
+
+	if !stats.LastErrorTime.IsZero() {
+		gauge(c.lastErrorMetric, float64(stats.LastErrorTime.UnixNano())/1e9)
+	}
+}
+
+// Publish publishes statistics of the writer and bulk requests by node via expvar.
+// Like expvar.Publish, it panics if the name is already registered.
+func (c *Collector) Publish(name string) {
+	expvar.Publish(name, expvar.Func(c.expvarValue))
+}
+
+// expvarStats is a value of the expvar variable.
+type expvarStats struct {
+	Writer    *elw.Stats            `json:"writer,omitempty"`
+	LastError string                `json:"last_error,omitempty"`
+	Nodes     map[string]*nodeStats `json:"nodes"`
+}
+
+func (c *Collector) expvarValue() interface{} {
+	var value expvarStats
+
+	if w, ok := c.writer.Load().(*elw.ElasticWriter); ok {
+		stats := w.Stats()
+
+		if stats.LastError != nil {
+			value.LastError = stats.LastError.Error()
+		}
+
+		stats.LastError = nil
+		value.Writer = &stats
+	}
+
+	value.Nodes = make(map[string]*nodeStats)
+
+	c.mu.Lock()
+
+	// nodes are copied, because expvar encodes the value after the call.
+	for host, node := range c.nodes {
+		requests := make(map[string]uint64, len(node.Requests))
+
+		for code, n := range node.Requests {
+			requests[code] = n
+		}
+
+		value.Nodes[host] = &nodeStats{
+			Requests:        requests,
+			DurationSeconds: node.DurationSeconds,
+			Bytes:           node.Bytes,
+		}
+	}
+
+	c.mu.Unlock()
+
+	return value
+}
+
+func boolValue(ok bool) float64 {
+	if ok {
+		return 1
+	}
+
+	return 0
+}
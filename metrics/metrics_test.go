@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gadavy/elw"
+	"github.com/gadavy/elw/transport"
+)
+
+func TestCollector(t *testing.T) {
+	const node = "http://127.0.0.1:9200"
+
+	collector := New(DefaultNamespace)
+
+	writer, err := elw.NewElasticWriter(elw.Config{
+		NodeURIs: []string{node},
+		Filepath: ":memory:",
+		OnBulk:   collector.ObserveBulk,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer writer.Close()
+
+	collector.Watch(writer)
+
+	_, _ = writer.Write([]byte(`{"message":"test"}`))
+
+	collector.ObserveBulk(transport.BulkResult{Node: node, StatusCode: 200, Size: 2048, Duration: time.Millisecond})
+	collector.ObserveBulk(transport.BulkResult{Node: node, Size: 2048, Err: errors.New("timeout")})
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(collector)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(map[string]bool)
+
+	for _, family := range families {
+		names[family.GetName()] = true
+	}
+
+	for _, name := range []string{
+		"elw_bulk_duration_seconds", "elw_bulk_requests_total", "elw_bulk_request_bytes",
+		"elw_documents_total", "elw_batches_total", "elw_storage_batches", "elw_connected", "elw_node_up",
+	} {
+		assert.True(t, names[name], name)
+	}
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(collector.bulkRequests.WithLabelValues(node, "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(collector.bulkRequests.WithLabelValues(node, statusError)))
+
+	value := collector.expvarValue().(expvarStats)
+
+	if assert.NotNil(t, value.Writer) {
+		assert.Equal(t, uint64(1), value.Writer.Documents)
+	}
+
+	assert.Equal(t, &nodeStats{
+		Requests:        map[string]uint64{"200": 1, statusError: 1},
+		DurationSeconds: time.Millisecond.Seconds(),
+		Bytes:           4096,
+	}, value.Nodes[node])
+}
@@ -0,0 +1,119 @@
+package elw
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/gadavy/elw/batch"
+	"github.com/gadavy/elw/internal"
+	"github.com/gadavy/elw/transport"
+)
+
+// OversizePolicy defines what to do with documents larger than MaxDocumentSize.
+type OversizePolicy int
+
+const (
+	// OversizeSend sends the document as a dedicated single-document request.
+	OversizeSend OversizePolicy = iota
+	// OversizeTruncate shortens TruncateField of the document, the document
+	// is sent as is if the field can't be shortened enough.
+	OversizeTruncate
+	// OversizeDrop passes the document to OnReject handler instead of the batch.
+	OversizeDrop
+)
+
+const (
+	truncatedSuffix     = "..."
+	documentTooLargeErr = "document_too_large"
+	requestTooLargeErr  = "request_entity_too_large"
+)
+
+// oversizeDocument returns the document to write according to the oversize policy,
+// ok is false if the document must be skipped.
+func (w *ElasticWriter) oversizeDocument(doc []byte) (res []byte, ok bool) {
+	switch w.oversizePolicy {
+	case OversizeTruncate:
+		if res, ok = internal.TruncateString(doc, w.truncateField, w.maxDocumentSize, truncatedSuffix); ok {
+			return res, true
+		}
+
+		return doc, true
+	case OversizeDrop:
+		w.reject(transport.FailedItem{
+			Action: batch.ActionIndex,
+			BulkItem: transport.BulkItem{
+				Error: &transport.BulkItemReason{
+					Type:   documentTooLargeErr,
+					Reason: "document size " + strconv.Itoa(len(doc)) + " exceeds " + strconv.Itoa(w.maxDocumentSize),
+				},
+			},
+		}, doc)
+
+		return nil, false
+	default:
+		return doc, true
+	}
+}
+
+// sendSplit sends actions of the body rejected with 413 status in two halves.
+// Single action that is still too large is rejected. Returns true if any action was retried.
+func (w *ElasticWriter) sendSplit(body []byte, actions []BulkIndexerItem) (retried bool) {
+	items := batch.Items(body)
+
+	if len(items) == 1 && len(actions) == 1 {
+		atomic.AddUint64(&w.stats.rejected, 1)
+
+		w.failAction(actions[0], transport.ErrRequestTooLarge)
+
+		return false
+	}
+
+	if len(items) == 1 {
+		w.reject(transport.FailedItem{
+			BulkItem: transport.BulkItem{
+				Index:  items[0].Index(),
+				Status: http.StatusRequestEntityTooLarge,
+				Error: &transport.BulkItemReason{
+					Type:   requestTooLargeErr,
+					Reason: transport.ErrRequestTooLarge.Error(),
+				},
+			},
+		}, items[0].Source)
+
+		return false
+	}
+
+	half := len(items) / 2
+
+	for i, part := range [][]batch.Item{items[:half], items[half:]} {
+		b := batch.NewBatch(len(body))
+
+		for _, item := range part {
+			b.AppendBytes(item.Meta)
+
+			if item.Source != nil {
+				b.AppendBytes(item.Source)
+			}
+		}
+
+		var partActions []BulkIndexerItem
+
+		if len(actions) == len(items) {
+			partActions = actions[:half]
+
+			if i > 0 {
+				partActions = actions[half:]
+			}
+		}
+
+		r, err := w.sendBulk(b.Bytes(), partActions)
+		if err != nil {
+			r = w.storeBatch(b.Bytes(), err, partActions) == nil
+		}
+
+		retried = retried || r
+	}
+
+	return retried
+}
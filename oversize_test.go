@@ -0,0 +1,128 @@
+package elw
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gadavy/elw/test"
+	"github.com/gadavy/elw/transport"
+)
+
+func TestElasticWriter_oversizeDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   OversizePolicy
+		doc      string
+		expected string
+		ok       bool
+		rejected bool
+	}{
+		{name: "Send", policy: OversizeSend, doc: `{"message":"abcdefghij"}`, expected: `{"message":"abcdefghij"}`, ok: true},
+		{name: "Truncate", policy: OversizeTruncate, doc: `{"message":"abcdefghij"}`, expected: `{"message":"abcde..."}`, ok: true},
+		{name: "TruncateMissing", policy: OversizeTruncate, doc: `{"msg":"abcdefghijkl"}`, expected: `{"msg":"abcdefghijkl"}`, ok: true},
+		{name: "Drop", policy: OversizeDrop, doc: `{"message":"abcdefghij"}`, rejected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rejections []Rejection
+
+			writer := &ElasticWriter{
+				maxDocumentSize: 22,
+				oversizePolicy:  tt.policy,
+				truncateField:   DefaultTruncateField,
+				onReject: func(r Rejection) {
+					rejections = append(rejections, r)
+				},
+			}
+
+			res, ok := writer.oversizeDocument([]byte(tt.doc))
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, string(res))
+
+			if assert.Equal(t, tt.rejected, len(rejections) == 1) && tt.rejected {
+				assert.Equal(t, documentTooLargeErr, rejections[0].ErrorType)
+			}
+		})
+	}
+}
+
+func TestElasticWriter_addOversizeDocument(t *testing.T) {
+	const (
+		small = `{"a":1}`
+		large = `{"message":"abcdefghij","service":"app"}`
+	)
+
+	writer := &ElasticWriter{
+		batchSize:       1000,
+		indexName:       "logs",
+		timeFormat:      DefaultTimeFormat,
+		maxDocumentSize: 10,
+		oversizePolicy:  OversizeSend,
+		transport:       &test.StubTransport{},
+
+		wg:    new(sync.WaitGroup),
+		queue: make(chan queuedBatch, 2),
+		stop:  make(chan struct{}),
+
+		rotatePeriod: time.Second,
+		timer:        time.NewTimer(time.Second),
+	}
+
+	writer.batch = writer.acquireBatch()
+
+	for _, doc := range []string{small, large, small} {
+		writer.addDocument(context.Background(), []byte(doc), nil)
+	}
+
+	if assert.Len(t, writer.queue, 2) {
+		assert.True(t, strings.HasSuffix((<-writer.queue).batch.String(), "\n"+small+"\n"))
+		assert.True(t, strings.HasSuffix((<-writer.queue).batch.String(), "\n"+large+"\n"))
+	}
+
+	assert.True(t, strings.HasSuffix((*writer.batch).String(), "\n"+small+"\n"))
+	assert.Equal(t, 2, strings.Count((*writer.batch).String(), "\n"))
+}
+
+func TestElasticWriter_sendSplit(t *testing.T) {
+	var (
+		first  = "{\"index\":{\"_index\":\"logs\"}}\n{\"a\":1}\n"
+		second = "{\"index\":{\"_index\":\"logs\"}}\n{\"b\":2}\n"
+		third  = "{\"index\":{\"_index\":\"logs\"}}\n{\"c\":3}\n"
+
+		rejections []Rejection
+	)
+
+	tr := &test.MockTransport{}
+	tr.On("SendBulk", []byte(first)).Return(nil)
+	tr.On("SendBulk", []byte(second+third)).Return(transport.ErrRequestTooLarge)
+	tr.On("SendBulk", []byte(second)).Return(nil)
+	tr.On("SendBulk", []byte(third)).Return(transport.ErrRequestTooLarge)
+
+	writer := &ElasticWriter{
+		transport: tr,
+		onReject: func(r Rejection) {
+			rejections = append(rejections, r)
+		},
+	}
+
+	retried := writer.sendSplit([]byte(first+second+third), nil)
+
+	assert.False(t, retried)
+	tr.AssertExpectations(t)
+
+	if assert.Len(t, rejections, 1) {
+		assert.Equal(t, Rejection{
+			Index:     "logs",
+			Status:    413,
+			ErrorType: requestTooLargeErr,
+			Reason:    transport.ErrRequestTooLarge.Error(),
+			Document:  []byte(`{"c":3}`),
+		}, rejections[0])
+	}
+}
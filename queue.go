@@ -0,0 +1,195 @@
+package elw
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/gadavy/elw/batch"
+)
+
+// OverflowPolicy defines what to do with a full batch when all senders are busy
+// and the queue of batches is full.
+type OverflowPolicy int
+
+const (
+	// OverflowSpill puts the batch to the storage, it's sent on reconnect.
+	OverflowSpill OverflowPolicy = iota
+	// OverflowBlock blocks the caller until the queue has free space.
+	OverflowBlock
+	// OverflowDropNewest drops the batch.
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest queued batch to free space for the new one.
+	OverflowDropOldest
+)
+
+var (
+	errBatchDropped = errors.New("batch dropped by overflow policy")
+	errBatchSpilled = errors.New("batch spilled to storage")
+)
+
+// QueueStats contains counters of queued batches by outcome.
+type QueueStats struct {
+	Queued        uint64
+	Blocked       uint64
+	Spilled       uint64
+	DroppedNewest uint64
+	DroppedOldest uint64
+}
+
+// queuedBatch is a full batch waiting for a sender.
+type queuedBatch struct {
+	batch   *batch.Batch
+	actions []BulkIndexerItem
+	group   *deliveryGroup
+}
+
+// queueCounters must be the first field of the writer to be 64-bit aligned.
+type queueCounters struct {
+	inFlight      int64
+	queued        uint64
+	blocked       uint64
+	spilled       uint64
+	droppedNewest uint64
+	droppedOldest uint64
+}
+
+// QueueStats returns counters of queued batches, safe for concurrent use.
+func (w *ElasticWriter) QueueStats() QueueStats {
+	return QueueStats{
+		Queued:        atomic.LoadUint64(&w.counters.queued),
+		Blocked:       atomic.LoadUint64(&w.counters.blocked),
+		Spilled:       atomic.LoadUint64(&w.counters.spilled),
+		DroppedNewest: atomic.LoadUint64(&w.counters.droppedNewest),
+		DroppedOldest: atomic.LoadUint64(&w.counters.droppedOldest),
+	}
+}
+
+// enqueueBatch passes the batch to senders according to the overflow policy.
+// Blocked caller spills the batch to the storage when the context is done.
+func (w *ElasticWriter) enqueueBatch(ctx context.Context, b *batch.Batch, actions []BulkIndexerItem, g *deliveryGroup) {
+	qb := queuedBatch{batch: b, actions: actions, group: g}
+
+	w.wg.Add(1)
+	atomic.AddInt64(&w.counters.inFlight, 1)
+	g.add()
+
+	select {
+	case <-w.stop:
+		// writer is closed, so there are no senders.
+		w.deliverBatch(qb)
+
+		return
+	default:
+	}
+
+	if w.queue == nil {
+		go w.deliverBatch(qb)
+
+		return
+	}
+
+	select {
+	case w.queue <- qb:
+		atomic.AddUint64(&w.counters.queued, 1)
+
+		return
+	default:
+	}
+
+	switch w.overflowPolicy {
+	case OverflowBlock:
+		atomic.AddUint64(&w.counters.blocked, 1)
+
+		select {
+		case w.queue <- qb:
+			atomic.AddUint64(&w.counters.queued, 1)
+		case <-ctx.Done():
+			atomic.AddUint64(&w.counters.spilled, 1)
+
+			w.spillBatch(qb)
+		}
+	case OverflowDropNewest:
+		atomic.AddUint64(&w.counters.droppedNewest, 1)
+
+		w.dropBatch(qb)
+	case OverflowDropOldest:
+		w.replaceOldestBatch(qb)
+	default:
+		atomic.AddUint64(&w.counters.spilled, 1)
+
+		w.spillBatch(qb)
+	}
+}
+
+func (w *ElasticWriter) replaceOldestBatch(qb queuedBatch) {
+	for {
+		select {
+		case w.queue <- qb:
+			atomic.AddUint64(&w.counters.queued, 1)
+
+			return
+		default:
+		}
+
+		select {
+		case old := <-w.queue:
+			atomic.AddUint64(&w.counters.droppedOldest, 1)
+
+			w.dropBatch(old)
+		default:
+		}
+	}
+}
+
+func (w *ElasticWriter) sender() {
+	for {
+		select {
+		case qb := <-w.queue:
+			w.deliverBatch(qb)
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// deliverBatch sends the batch and reports the result to its delivery group.
+func (w *ElasticWriter) deliverBatch(qb queuedBatch) {
+	qb.group.finish(w.releaseBatch(qb.batch, qb.actions))
+}
+
+func (w *ElasticWriter) spillBatch(qb queuedBatch) {
+	err := w.storeBatch(qb.batch.Bytes(), errBatchSpilled, qb.actions)
+
+	w.recycleBatch(qb.batch)
+	qb.group.finish(err)
+}
+
+func (w *ElasticWriter) dropBatch(qb queuedBatch) {
+	w.logf(levelError, "batch of %d bytes dropped: %v", qb.batch.Len(), errBatchDropped)
+
+	if w.hooks != nil {
+		w.hooks.OnBatchDropped(qb.batch.Len(), errBatchDropped)
+	}
+
+	w.failActions(qb.actions, errBatchDropped)
+	w.recycleBatch(qb.batch)
+	qb.group.finish(errBatchDropped)
+}
+
+// stopSenders stops senders of the closed writer, so next batches are released by the caller.
+func (w *ElasticWriter) stopSenders() {
+	w.stopOnce.Do(func() {
+		if w.stop != nil {
+			close(w.stop)
+		}
+	})
+}
+
+// recycleBatch returns released batch to the pool.
+func (w *ElasticWriter) recycleBatch(b *batch.Batch) {
+	b.Reset()
+	w.batchPool.Put(b)
+	atomic.AddInt64(&w.counters.inFlight, -1)
+	w.wg.Done()
+}
@@ -0,0 +1,128 @@
+package elw
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gadavy/elw/batch"
+	"github.com/gadavy/elw/test"
+)
+
+func TestElasticWriter_enqueueBatch(t *testing.T) {
+	newBatch := func(data string) *batch.Batch {
+		b := batch.NewBatch(0)
+		b.AppendBytes([]byte(data))
+
+		return b
+	}
+
+	tests := []struct {
+		name          string
+		policy        OverflowPolicy
+		storage       *test.MockStorage
+		storagePutIn  []interface{}
+		storagePutOut []interface{}
+		expectedQueue string
+		expectedStats QueueStats
+	}{
+		{
+			name:          "Spill",
+			policy:        OverflowSpill,
+			storage:       &test.MockStorage{},
+			storagePutIn:  []interface{}{[]byte("newest\n")},
+			storagePutOut: []interface{}{(error)(nil)},
+			expectedQueue: "oldest\n",
+			expectedStats: QueueStats{Queued: 1, Spilled: 1},
+		},
+		{
+			name:          "DropNewest",
+			policy:        OverflowDropNewest,
+			storage:       &test.MockStorage{},
+			expectedQueue: "oldest\n",
+			expectedStats: QueueStats{Queued: 1, DroppedNewest: 1},
+		},
+		{
+			name:          "DropOldest",
+			policy:        OverflowDropOldest,
+			storage:       &test.MockStorage{},
+			expectedQueue: "newest\n",
+			expectedStats: QueueStats{Queued: 2, DroppedOldest: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.storagePutIn != nil {
+				tt.storage.On("Put", tt.storagePutIn...).Return(tt.storagePutOut...)
+			}
+
+			writer := ElasticWriter{
+				storage:        tt.storage,
+				wg:             new(sync.WaitGroup),
+				queue:          make(chan queuedBatch, 1),
+				overflowPolicy: tt.policy,
+			}
+
+			writer.enqueueBatch(context.Background(), newBatch("oldest"), nil, nil)
+			writer.enqueueBatch(context.Background(), newBatch("newest"), nil, nil)
+
+			assert.Equal(t, tt.expectedQueue, (<-writer.queue).batch.String())
+			assert.Equal(t, tt.expectedStats, writer.QueueStats())
+
+			tt.storage.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Block", func(t *testing.T) {
+		writer := ElasticWriter{
+			wg:             new(sync.WaitGroup),
+			queue:          make(chan queuedBatch, 1),
+			overflowPolicy: OverflowBlock,
+		}
+
+		writer.enqueueBatch(context.Background(), newBatch("oldest"), nil, nil)
+
+		done := make(chan struct{})
+
+		go func() {
+			writer.enqueueBatch(context.Background(), newBatch("newest"), nil, nil)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			t.Error("enqueue should block")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		assert.Equal(t, "oldest\n", (<-writer.queue).batch.String())
+
+		<-done
+
+		assert.Equal(t, "newest\n", (<-writer.queue).batch.String())
+		assert.Equal(t, QueueStats{Queued: 2, Blocked: 1}, writer.QueueStats())
+	})
+
+	t.Run("Stopped", func(t *testing.T) {
+		tr := &test.MockTransport{}
+		tr.On("IsConnected").Return(true)
+		tr.On("SendBulk", []byte("message\n")).Return((error)(nil))
+
+		writer := ElasticWriter{
+			transport: tr,
+			wg:        new(sync.WaitGroup),
+			queue:     make(chan queuedBatch, 1),
+			stop:      make(chan struct{}),
+		}
+
+		writer.stopSenders()
+		writer.enqueueBatch(context.Background(), newBatch("message"), nil, nil)
+
+		assert.Len(t, writer.queue, 0)
+
+		tr.AssertExpectations(t)
+	})
+}
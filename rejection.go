@@ -0,0 +1,86 @@
+package elw
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gadavy/elw/batch"
+	"github.com/gadavy/elw/transport"
+)
+
+// Rejection describes a document refused by Elasticsearch with non-retriable error.
+type Rejection struct {
+	Index     string
+	Status    int
+	ErrorType string
+	Reason    string
+	// Document is a source of the rejected action, valid only during the handler call.
+	Document []byte
+}
+
+// RejectHandler is called for each rejected document.
+type RejectHandler func(r Rejection)
+
+// deadLetter is a document indexed into the dead-letter index instead of the rejected one.
+type deadLetter struct {
+	Timestamp string `json:"@timestamp"`
+	Index     string `json:"index"`
+	Status    int    `json:"status"`
+	ErrorType string `json:"error_type"`
+	Reason    string `json:"reason"`
+	Document  string `json:"document"`
+}
+
+// isDeadLetterEnabled reports whether document rejected by index should be indexed
+// into the dead-letter index. Rejected dead letters are never wrapped again.
+func (w *ElasticWriter) isDeadLetterEnabled(index string) bool {
+	return w.deadLetterIndex != "" && !w.isDeadLetterIndex(index)
+}
+
+// isDeadLetterIndex reports whether index is a daily index written by appendDeadLetter.
+func (w *ElasticWriter) isDeadLetterIndex(index string) bool {
+	prefix := w.deadLetterIndex + "-"
+	if !strings.HasPrefix(index, prefix) {
+		return false
+	}
+
+	_, err := time.Parse(w.timeFormat, index[len(prefix):])
+
+	return err == nil
+}
+
+func (w *ElasticWriter) appendDeadLetter(b *batch.Batch, failed transport.FailedItem, doc []byte) {
+	data, err := json.Marshal(deadLetter{
+		Timestamp: time.Now().Format(time.RFC3339Nano),
+		Index:     failed.Index,
+		Status:    failed.Status,
+		ErrorType: failed.Error.Type,
+		Reason:    failed.Error.Reason,
+		Document:  string(doc),
+	})
+	if err != nil {
+		return
+	}
+
+	index := append([]byte(w.deadLetterIndex+"-"), time.Now().Format(w.timeFormat)...)
+
+	b.AppendAction(batch.Meta{
+		Index: index,
+		Type:  w.transport.Version().DocType(),
+	})
+	b.AppendBytes(data)
+}
+
+// sendDeadLetters delivers dead letters in place, because writer's batch
+// can be locked by Close. Returns true if any dead letter should be retried.
+func (w *ElasticWriter) sendDeadLetters(b *batch.Batch) bool {
+	retried, err := w.sendBulk(b.Bytes(), nil)
+	if err == nil {
+		return retried
+	}
+
+	_ = w.storeBatch(b.Bytes(), err, nil)
+
+	return false
+}
@@ -0,0 +1,289 @@
+package elw
+
+import (
+	"sync/atomic"
+
+	"github.com/gadavy/elw/batch"
+	"github.com/gadavy/elw/transport"
+)
+
+// releaseBatch sends the batch or puts it to the storage. Returns error if neither happened.
+// Actions of the bulk indexer are passed in the order of the request body, so their callbacks
+// get the outcome. Batches of the writer have no actions.
+func (w *ElasticWriter) releaseBatch(b *batch.Batch, actions []BulkIndexerItem) error {
+	defer w.recycleBatch(b)
+
+	switch w.transport.IsConnected() {
+	case true:
+		_, err := w.sendBulk(b.Bytes(), actions)
+		if err == nil {
+			atomic.AddUint64(&w.stats.batchesSent, 1)
+
+			if w.hooks != nil {
+				w.hooks.OnBatchSent(b.Len())
+			}
+
+			return nil
+		}
+
+		w.setLastError(err)
+		atomic.AddUint64(&w.stats.batchesFailed, 1)
+
+		return w.storeBatch(b.Bytes(), err, actions)
+	default:
+		atomic.AddUint64(&w.stats.batchesFailed, 1)
+
+		return w.storeBatch(b.Bytes(), nil, actions)
+	}
+}
+
+// sendBulk sends the bulk request and handles its partial failure: rejected actions
+// are reported, retriable ones are put to the storage and too large request is split.
+// Returns true if any action was retried and error if the request wasn't sent at all.
+func (w *ElasticWriter) sendBulk(body []byte, actions []BulkIndexerItem) (retried bool, err error) {
+	err = w.transport.SendBulk(body)
+	if err == nil {
+		w.succeedActions(actions)
+
+		return false, nil
+	}
+
+	if bulkErr, ok := err.(*transport.BulkError); ok {
+		return w.retryFailed(body, bulkErr, actions), nil
+	}
+
+	if err == transport.ErrRequestTooLarge {
+		return w.sendSplit(body, actions), nil
+	}
+
+	return false, err
+}
+
+// storeBatch puts the batch which wasn't sent because of the cause to the storage.
+func (w *ElasticWriter) storeBatch(body []byte, cause error, actions []BulkIndexerItem) error {
+	err := w.storage.Put(w.storedBatch(body))
+	if err == nil {
+		if cause != nil {
+			w.logf(levelWarn, "batch of %d bytes put to storage: %v", len(body), cause)
+		}
+
+		if w.hooks != nil {
+			w.hooks.OnBatchStored(len(body), cause)
+		}
+
+		w.storeActions(actions)
+
+		return nil
+	}
+
+	w.setLastError(err)
+
+	if w.hooks != nil {
+		w.hooks.OnBatchDropped(len(body), err)
+	}
+
+	w.logf(levelError, "release batch = %s failed: %v", body, err)
+
+	w.failActions(actions, err)
+
+	return err
+}
+
+// releaseStorage sends stored batches until the storage is empty or the cluster is overloaded.
+// Stored batches have no actions, so rejected documents are passed to OnReject.
+func (w *ElasticWriter) releaseStorage() {
+	var (
+		buf, body []byte
+		ids       IDStrategy
+		retried   bool
+		replayed  int
+		err       error
+	)
+
+	defer func() {
+		if replayed > 0 {
+			w.logf(levelInfo, "replayed %d batches from storage", replayed)
+		}
+	}()
+
+	for w.transport.IsConnected() && w.storage.IsUsed() {
+		if buf, err = w.storage.Pop(); err != nil {
+			w.logf(levelError, "pop batch from storage failed: %v", err)
+
+			continue
+		}
+
+		ids, body = parseStoredBatch(buf)
+
+		w.logf(levelDebug, "replay batch of %d bytes with %s document IDs", len(body), ids)
+
+		retried, err = w.sendBulk(body, nil)
+
+		if w.hooks != nil {
+			w.hooks.OnReplay(len(body), err)
+		}
+
+		if err == nil {
+			atomic.AddUint64(&w.stats.batchesReplayed, 1)
+
+			replayed++
+
+			// cluster is overloaded, so stop replaying until the next attempt.
+			if retried {
+				return
+			}
+
+			continue
+		}
+
+		w.setLastError(err)
+		w.logf(levelWarn, "replay batch of %d bytes failed, put back to storage: %v", len(body), err)
+
+		if err = w.storage.Put(buf); err == nil {
+			continue
+		}
+
+		w.setLastError(err)
+
+		if w.hooks != nil {
+			w.hooks.OnBatchDropped(len(body), err)
+		}
+
+		w.logf(levelError, "release batch = %s failed: %v", body, err)
+	}
+}
+
+// retryFailed puts retriable actions of the bulk request to the storage
+// and reports about rejected ones. Returns true if any action was retried.
+func (w *ElasticWriter) retryFailed(body []byte, bulkErr *transport.BulkError, actions []BulkIndexerItem) bool {
+	var (
+		items        = batch.Items(body)
+		retry        = batch.NewBatch(len(body))
+		retryActions []BulkIndexerItem
+		deadLetters  = batch.NewBatch(0)
+		failed       = make(map[int]bool, len(bulkErr.Items))
+		retried      bool
+	)
+
+	for _, f := range bulkErr.Items {
+		if f.Position >= len(items) {
+			continue
+		}
+
+		item := items[f.Position]
+
+		if f.IsRetriable() {
+			retry.AppendBytes(item.Meta)
+
+			if item.Source != nil {
+				retry.AppendBytes(item.Source)
+			}
+
+			if f.Position < len(actions) {
+				retryActions = append(retryActions, actions[f.Position])
+			}
+
+			failed[f.Position] = true
+
+			continue
+		}
+
+		// actions have IDs of the caller, so conflicts are reported to them.
+		if f.Position < len(actions) {
+			failed[f.Position] = true
+
+			atomic.AddUint64(&w.stats.rejected, 1)
+
+			w.failAction(actions[f.Position], &ActionError{FailedItem: f})
+
+			continue
+		}
+
+		// documents with generated IDs are already delivered.
+		if isDuplicate(f, item) {
+			continue
+		}
+
+		w.reject(f, item.Source)
+
+		if w.isDeadLetterEnabled(f.Index) {
+			w.appendDeadLetter(deadLetters, f, item.Source)
+		}
+	}
+
+	for i, action := range actions {
+		if !failed[i] {
+			w.succeedAction(action)
+		}
+	}
+
+	if deadLetters.Len() > 0 {
+		retried = w.sendDeadLetters(deadLetters)
+	}
+
+	if retry.Len() == 0 {
+		return retried
+	}
+
+	_ = w.storeBatch(retry.Bytes(), bulkErr, retryActions)
+
+	return true
+}
+
+func (w *ElasticWriter) reject(failed transport.FailedItem, doc []byte) {
+	atomic.AddUint64(&w.stats.rejected, 1)
+
+	if w.onReject != nil {
+		w.onReject(Rejection{
+			Index:     failed.Index,
+			Status:    failed.Status,
+			ErrorType: failed.Error.Type,
+			Reason:    failed.Error.Reason,
+			Document:  doc,
+		})
+
+		return
+	}
+
+	w.logf(levelWarn, "document = %s rejected by index %s: %s: %s",
+		doc, failed.Index, failed.Error.Type, failed.Error.Reason)
+}
+
+func (w *ElasticWriter) succeedAction(action BulkIndexerItem) {
+	atomic.AddUint64(&w.stats.actionsSucceeded, 1)
+
+	if action.OnSuccess != nil {
+		action.OnSuccess(action)
+	}
+}
+
+func (w *ElasticWriter) succeedActions(actions []BulkIndexerItem) {
+	for _, action := range actions {
+		w.succeedAction(action)
+	}
+}
+
+func (w *ElasticWriter) failAction(action BulkIndexerItem, err error) {
+	atomic.AddUint64(&w.stats.actionsFailed, 1)
+
+	if action.OnFailure != nil {
+		action.OnFailure(action, err)
+	}
+}
+
+func (w *ElasticWriter) failActions(actions []BulkIndexerItem, err error) {
+	for _, action := range actions {
+		w.failAction(action, err)
+	}
+}
+
+// storeActions reports actions put to the storage, they are sent on reconnect without callbacks.
+func (w *ElasticWriter) storeActions(actions []BulkIndexerItem) {
+	atomic.AddUint64(&w.stats.actionsStored, uint64(len(actions)))
+
+	for _, action := range actions {
+		if action.OnFailure != nil {
+			action.OnFailure(action, ErrActionStored)
+		}
+	}
+}
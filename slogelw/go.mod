@@ -0,0 +1,20 @@
+module github.com/gadavy/elw/slogelw
+
+go 1.21
+
+require (
+	github.com/gadavy/elw v0.0.0-20261018091917-6dd803b0c9ad
+	github.com/stretchr/testify v1.4.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/klauspost/compress v1.8.2 // indirect
+	github.com/klauspost/cpuid v1.2.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasthttp v1.9.0 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+)
+
+replace github.com/gadavy/elw => ../
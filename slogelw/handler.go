@@ -0,0 +1,273 @@
+// Package slogelw is a log/slog handler writing records to Elasticsearch via elw.ElasticWriter.
+//
+// Records are encoded as ECS-style JSON documents: @timestamp, log.level, message
+// and log.origin.* fields. Groups are nested JSON objects, attributes added by
+// WithAttrs are encoded once:
+//
+//	writer, err := elw.NewElasticWriter(elw.Config{TimestampFields: []string{"@timestamp"}})
+//	logger := slog.New(slogelw.NewHandler(writer, &slogelw.Options{AddSource: true}))
+//	defer writer.Close()
+package slogelw
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gadavy/elw"
+)
+
+// errorKey is a key of top-level error attributes, which are mapped to error.message and error.type.
+const errorKey = "error"
+
+type Options struct {
+	// Level enables records, slog.LevelInfo by default.
+	Level slog.Leveler
+	// AddSource writes source position of records as log.origin.* fields.
+	AddSource bool
+}
+
+// Handler is a slog.Handler writing a JSON document per record, safe for concurrent use.
+type Handler struct {
+	w         io.Writer
+	level     slog.Leveler
+	addSource bool
+
+	// pre contains encoded attributes of WithAttrs inside opened groups,
+	// groups after opened ones are written only by records with attributes.
+	pre    []byte
+	groups []string
+	opened int
+}
+
+var bufPool = sync.Pool{New: func() any {
+	b := make([]byte, 0, 1024)
+
+	return &b
+}}
+
+// NewHandler returns handler writing to w, usually elw.ElasticWriter.
+func NewHandler(w io.Writer, opts *Options) *Handler {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	h := &Handler{w: w, level: opts.Level, addSource: opts.AddSource}
+
+	if h.level == nil {
+		h.level = slog.LevelInfo
+	}
+
+	return h
+}
+
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	clone := h.clone()
+
+	for _, g := range clone.groups[clone.opened:] {
+		clone.pre = appendGroupStart(clone.pre, g)
+	}
+
+	clone.opened = len(clone.groups)
+
+	for _, a := range attrs {
+		clone.pre = appendAttr(clone.pre, a, clone.opened == 0)
+	}
+
+	return clone
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	clone := h.clone()
+	clone.groups = append(clone.groups, name)
+
+	return clone
+}
+
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	bp := bufPool.Get().(*[]byte)
+	buf := (*bp)[:0]
+
+	defer func() {
+		*bp = buf
+		bufPool.Put(bp)
+	}()
+
+	buf = append(buf, '{')
+
+	if !r.Time.IsZero() {
+		buf = appendKey(buf, elw.TimestampKey)
+		buf = elw.AppendQuoted(buf, r.Time.Format(time.RFC3339Nano))
+	}
+
+	buf = appendKey(buf, elw.LevelKey)
+	buf = elw.AppendQuoted(buf, strings.ToLower(r.Level.String()))
+	buf = appendKey(buf, elw.MessageKey)
+	buf = elw.AppendQuoted(buf, r.Message)
+
+	if h.addSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+
+		buf = appendKey(buf, elw.FileNameKey)
+		buf = elw.AppendQuoted(buf, frame.File)
+		buf = appendKey(buf, elw.FileLineKey)
+		buf = strconv.AppendInt(buf, int64(frame.Line), 10)
+		buf = appendKey(buf, elw.FunctionKey)
+		buf = elw.AppendQuoted(buf, frame.Function)
+	}
+
+	buf = append(buf, h.pre...)
+
+	if r.NumAttrs() > 0 {
+		for _, g := range h.groups[h.opened:] {
+			buf = appendGroupStart(buf, g)
+		}
+
+		r.Attrs(func(a slog.Attr) bool {
+			buf = appendAttr(buf, a, len(h.groups) == 0)
+
+			return true
+		})
+
+		for range h.groups[h.opened:] {
+			buf = append(buf, '}')
+		}
+	}
+
+	for i := 0; i < h.opened; i++ {
+		buf = append(buf, '}')
+	}
+
+	buf = append(buf, '}', '\n')
+
+	_, err := h.w.Write(buf)
+
+	return err
+}
+
+func (h *Handler) clone() *Handler {
+	clone := *h
+	clone.pre = append([]byte(nil), h.pre...)
+	clone.groups = append([]string(nil), h.groups...)
+
+	return &clone
+}
+
+// appendAttr appends the attribute, empty attributes and groups are skipped,
+// groups with empty key are inlined.
+func appendAttr(buf []byte, a slog.Attr, top bool) []byte {
+	a.Value = a.Value.Resolve()
+
+	if a.Equal(slog.Attr{}) {
+		return buf
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return buf
+		}
+
+		if a.Key != "" {
+			buf = appendGroupStart(buf, a.Key)
+			top = false
+		}
+
+		for _, ga := range attrs {
+			buf = appendAttr(buf, ga, top)
+		}
+
+		if a.Key != "" {
+			buf = append(buf, '}')
+		}
+
+		return buf
+	}
+
+	if err, ok := a.Value.Any().(error); ok && top && a.Key == errorKey {
+		buf = appendKey(buf, elw.ErrorMessageKey)
+		buf = elw.AppendQuoted(buf, err.Error())
+		buf = appendKey(buf, elw.ErrorTypeKey)
+
+		return elw.AppendQuoted(buf, fmt.Sprintf("%T", err))
+	}
+
+	buf = appendKey(buf, a.Key)
+
+	return appendValue(buf, a.Value)
+}
+
+func appendValue(buf []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return elw.AppendQuoted(buf, v.String())
+	case slog.KindInt64:
+		return strconv.AppendInt(buf, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(buf, v.Uint64(), 10)
+	case slog.KindFloat64:
+		if f := v.Float64(); !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return strconv.AppendFloat(buf, f, 'g', -1, 64)
+		}
+
+		return elw.AppendQuoted(buf, v.String())
+	case slog.KindBool:
+		return strconv.AppendBool(buf, v.Bool())
+	case slog.KindDuration:
+		return strconv.AppendInt(buf, int64(v.Duration()), 10)
+	case slog.KindTime:
+		return elw.AppendQuoted(buf, v.Time().Format(time.RFC3339Nano))
+	}
+
+	value := v.Any()
+
+	if err, ok := value.(error); ok {
+		return elw.AppendQuoted(buf, err.Error())
+	}
+
+	// unsupported values are written as strings, so the record isn't lost.
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return elw.AppendQuoted(buf, fmt.Sprint(value))
+	}
+
+	return append(buf, raw...)
+}
+
+func appendGroupStart(buf []byte, name string) []byte {
+	buf = appendKey(buf, name)
+
+	return append(buf, '{')
+}
+
+// appendKey appends the key preceded by comma unless it's the first field of an object.
+// Attributes encoded separately, e.g. by WithAttrs, start with comma.
+func appendKey(buf []byte, key string) []byte {
+	if len(buf) == 0 || buf[len(buf)-1] != '{' {
+		buf = append(buf, ',')
+	}
+
+	buf = elw.AppendQuoted(buf, key)
+
+	return append(buf, ':')
+}
@@ -0,0 +1,122 @@
+package slogelw
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gadavy/elw"
+)
+
+var _ io.Writer = (*elw.ElasticWriter)(nil)
+
+func TestHandler_Slogtest(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	err := slogtest.TestHandler(NewHandler(buf, nil), func() []map[string]any {
+		var docs []map[string]any
+
+		for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+			var doc map[string]any
+
+			if err := json.Unmarshal(line, &doc); err != nil {
+				t.Fatal(err)
+			}
+
+			// slogtest expects built-in keys.
+			for key, builtin := range map[string]string{
+				elw.TimestampKey: slog.TimeKey,
+				elw.LevelKey:     slog.LevelKey,
+				elw.MessageKey:   slog.MessageKey,
+			} {
+				if value, ok := doc[key]; ok {
+					doc[builtin] = value
+					delete(doc, key)
+				}
+			}
+
+			docs = append(docs, doc)
+		}
+
+		return docs
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandler_Handle(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	logger := slog.New(NewHandler(buf, &Options{Level: slog.LevelDebug, AddSource: true})).
+		With("service", "api").
+		WithGroup("http").
+		With(slog.Int("status", 200)).
+		WithGroup("request")
+
+	logger.Debug("test message", "method", "GET", "error", os.ErrNotExist)
+
+	var doc map[string]any
+
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, doc, elw.TimestampKey)
+	assert.Equal(t, "debug", doc[elw.LevelKey])
+	assert.Equal(t, "test message", doc[elw.MessageKey])
+	assert.Equal(t, "api", doc["service"])
+	assert.Equal(t, map[string]any{
+		"status":  float64(200),
+		"request": map[string]any{"method": "GET", "error": os.ErrNotExist.Error()},
+	}, doc["http"])
+	assert.Contains(t, doc[elw.FileNameKey], "slogelw/handler_test.go")
+	assert.Contains(t, doc, elw.FileLineKey)
+	assert.Contains(t, doc[elw.FunctionKey], "TestHandler_Handle")
+}
+
+func TestHandler_Enabled(t *testing.T) {
+	h := NewHandler(io.Discard, &Options{Level: slog.LevelWarn})
+
+	assert.False(t, h.Enabled(context.Background(), slog.LevelInfo))
+	assert.True(t, h.Enabled(context.Background(), slog.LevelError))
+}
+
+func TestAppendAttr(t *testing.T) {
+	tests := []struct {
+		name     string
+		attr     slog.Attr
+		expected string
+	}{
+		{name: "String", attr: slog.String("k", "a\"b"), expected: `,"k":"a\"b"`},
+		{name: "Float", attr: slog.Float64("k", 1.5), expected: `,"k":1.5`},
+		{name: "NaN", attr: slog.Float64("k", math.NaN()), expected: `,"k":"NaN"`},
+		{name: "Duration", attr: slog.Duration("k", time.Second), expected: `,"k":1000000000`},
+		{name: "Time", attr: slog.Time("k", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)), expected: `,"k":"2020-01-02T03:04:05Z"`},
+		{name: "Error", attr: slog.Any("error", errors.New("x")), expected: `,"error.message":"x","error.type":"*errors.errorString"`},
+		{name: "Unsupported", attr: slog.Any("k", func() {}), expected: `,"k":"0x`},
+		{name: "InlineGroup", attr: slog.Group("", slog.Int("a", 1)), expected: `,"a":1`},
+		{name: "EmptyGroup", attr: slog.Group("g"), expected: ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := string(appendAttr(nil, tt.attr, true))
+
+			if tt.expected == "" {
+				assert.Empty(t, res)
+			} else {
+				assert.Contains(t, res, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package elw
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/gadavy/elw/transport"
+)
+
+// Stats contains counters and gauges of the writer.
+type Stats struct {
+	QueueStats
+
+	// Documents is a number of documents written to batches.
+	Documents uint64
+	// Bytes is a size of documents written to batches.
+	Bytes uint64
+	// Rejected is a number of documents rejected by Elasticsearch or dropped on write.
+	Rejected uint64
+
+	// BatchesSent is a number of batches accepted by Elasticsearch.
+	BatchesSent uint64
+	// BatchesFailed is a number of batches which were not sent and put to the storage.
+	BatchesFailed uint64
+	// BatchesReplayed is a number of batches sent from the storage.
+	BatchesReplayed uint64
+	// InFlight is a number of batches queued or being sent.
+	InFlight int
+
+	// StorageBatches is a number of batches kept by the storage.
+	StorageBatches int
+	// StorageBytes is a size of batches kept by the storage.
+	StorageBytes int64
+	// StorageAge is an age of the oldest batch kept by the storage.
+	StorageAge time.Duration
+
+	// LastError is the last error of sending or storing batches, nil if none.
+	LastError error
+	// LastErrorTime is a time of the last error.
+	LastErrorTime time.Time
+
+	// Connected reports whether there are live nodes.
+	Connected bool
+	// Nodes contains status of each node.
+	Nodes []transport.NodeStatus
+}
+
+// writerCounters must follow queueCounters to be 64-bit aligned.
+type writerCounters struct {
+	documents       uint64
+	bytes           uint64
+	rejected        uint64
+	batchesSent     uint64
+	batchesFailed   uint64
+	batchesReplayed uint64
+
+	actionsFlushed   uint64
+	actionsSucceeded uint64
+	actionsFailed    uint64
+	actionsStored    uint64
+
+	lastError atomic.Value
+}
+
+type lastError struct {
+	err  error
+	time time.Time
+}
+
+// Stats returns statistics of the writer, safe for concurrent use.
+// Storage statistics of the file storage are read from disk.
+func (w *ElasticWriter) Stats() Stats {
+	stats := Stats{
+		QueueStats:      w.QueueStats(),
+		Documents:       atomic.LoadUint64(&w.stats.documents),
+		Bytes:           atomic.LoadUint64(&w.stats.bytes),
+		Rejected:        atomic.LoadUint64(&w.stats.rejected),
+		BatchesSent:     atomic.LoadUint64(&w.stats.batchesSent),
+		BatchesFailed:   atomic.LoadUint64(&w.stats.batchesFailed),
+		BatchesReplayed: atomic.LoadUint64(&w.stats.batchesReplayed),
+		InFlight:        int(atomic.LoadInt64(&w.counters.inFlight)),
+		Connected:       w.transport.IsConnected(),
+		Nodes:           w.transport.Nodes(),
+	}
+
+	if last, ok := w.stats.lastError.Load().(lastError); ok {
+		stats.LastError = last.err
+		stats.LastErrorTime = last.time
+	}
+
+	storageStats := w.storage.Stats()
+
+	stats.StorageBatches = storageStats.Batches
+	stats.StorageBytes = storageStats.Bytes
+
+	if !storageStats.Oldest.IsZero() {
+		stats.StorageAge = time.Since(storageStats.Oldest)
+	}
+
+	return stats
+}
+
+func (w *ElasticWriter) setLastError(err error) {
+	w.stats.lastError.Store(lastError{err: err, time: time.Now()})
+}
@@ -0,0 +1,60 @@
+package elw
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gadavy/elw/batch"
+	"github.com/gadavy/elw/internal"
+	"github.com/gadavy/elw/storage"
+	"github.com/gadavy/elw/test"
+)
+
+func TestElasticWriter_Stats(t *testing.T) {
+	sendErr := errors.New("transport error")
+
+	tr := &test.MockTransport{}
+	tr.On("IsConnected").Return(true, true, true)
+	tr.On("SendBulk", []byte("sent\n")).Return(nil)
+	tr.On("SendBulk", []byte("failed\n")).Return(sendErr)
+
+	st, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writer := &ElasticWriter{
+		batchSize: 1024,
+		transport: tr,
+		storage:   st,
+		mu:        internal.NewMutex(),
+		wg:        new(sync.WaitGroup),
+	}
+	writer.batch = writer.acquireBatch()
+
+	_, _ = writer.Write([]byte("first\nsecond"))
+
+	for _, body := range []string{"sent", "failed"} {
+		b := batch.NewBatch(0)
+		b.AppendBytes([]byte(body))
+
+		writer.wg.Add(1)
+		writer.releaseBatch(b, nil)
+	}
+
+	stats := writer.Stats()
+
+	assert.Equal(t, uint64(2), stats.Documents)
+	assert.Equal(t, uint64(11), stats.Bytes)
+	assert.Equal(t, uint64(1), stats.BatchesSent)
+	assert.Equal(t, uint64(1), stats.BatchesFailed)
+	assert.Equal(t, 1, stats.StorageBatches)
+	assert.Equal(t, int64(7), stats.StorageBytes)
+	assert.True(t, stats.StorageAge >= 0)
+	assert.Equal(t, sendErr, stats.LastError)
+	assert.False(t, stats.LastErrorTime.IsZero())
+	assert.True(t, stats.Connected)
+}
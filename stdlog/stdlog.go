@@ -0,0 +1,90 @@
+// Package stdlog adapts the standard log.Logger to elw.ElasticWriter.
+// Each message of the logger is written as a JSON document:
+//
+//	writer, err := elw.NewElasticWriter(elw.Config{TimestampFields: []string{"@timestamp"}})
+//	logger := stdlog.New(writer, map[string]interface{}{"service": "api"})
+//	logger.Println("test message to elastic")
+package stdlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gadavy/elw"
+	"github.com/gadavy/elw/internal"
+)
+
+// Writer turns plain-text messages into JSON documents with @timestamp, message and static fields.
+// Every Write is a single message, so multiline messages aren't split. Safe for concurrent use.
+type Writer struct {
+	w      io.Writer
+	fields []byte
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// NewWriter returns writer to w, usually elw.ElasticWriter. Fields are added to every document,
+// fields which can't be encoded to JSON are skipped.
+func NewWriter(w io.Writer, fields map[string]interface{}) *Writer {
+	return &Writer{w: w, fields: encodeFields(fields)}
+}
+
+// New returns logger without flags, the time is written as @timestamp field.
+func New(w io.Writer, fields map[string]interface{}) *log.Logger {
+	return log.New(NewWriter(w, fields), "", 0)
+}
+
+func (w *Writer) Write(p []byte) (n int, err error) {
+	msg := bytes.TrimRight(p, "\r\n")
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf[:0], `{"`+elw.TimestampKey+`":"`...)
+	w.buf = time.Now().AppendFormat(w.buf, time.RFC3339Nano)
+	w.buf = append(w.buf, `","`+elw.MessageKey+`":`...)
+	w.buf = internal.AppendQuotedBytes(w.buf, msg)
+	w.buf = append(w.buf, w.fields...)
+	w.buf = append(w.buf, '}', '\n')
+
+	if _, err = w.w.Write(w.buf); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// encodeFields returns sorted fields as ,"key":value pairs.
+func encodeFields(fields map[string]interface{}) []byte {
+	keys := make([]string, 0, len(fields))
+
+	for key := range fields {
+		if key != elw.TimestampKey && key != elw.MessageKey {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	var res []byte
+
+	for _, key := range keys {
+		value, err := json.Marshal(fields[key])
+		if err != nil {
+			continue
+		}
+
+		res = append(res, ',')
+		res = internal.AppendQuoted(res, key)
+		res = append(res, ':')
+		res = append(res, value...)
+	}
+
+	return res
+}
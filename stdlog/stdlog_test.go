@@ -0,0 +1,42 @@
+package stdlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gadavy/elw"
+)
+
+func TestWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	logger := New(buf, map[string]interface{}{
+		"service":     "api",
+		"version":     2,
+		"message":     "skipped",
+		"unsupported": func() {},
+	})
+
+	logger.Println("first line\n\"second\" line")
+
+	var doc map[string]interface{}
+
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, doc, elw.TimestampKey)
+	assert.Equal(t, "first line\n\"second\" line", doc[elw.MessageKey])
+	assert.Equal(t, "api", doc["service"])
+	assert.Equal(t, float64(2), doc["version"])
+	assert.NotContains(t, doc, "unsupported")
+	assert.Equal(t, byte('\n'), buf.Bytes()[buf.Len()-1])
+}
+
+func TestEncodeFields(t *testing.T) {
+	assert.Equal(t, `,"a":1,"b":"x"`, string(encodeFields(map[string]interface{}{"b": "x", "a": 1})))
+	assert.Empty(t, encodeFields(nil))
+}
@@ -17,6 +17,15 @@ type Storage interface {
 	Pop() ([]byte, error)
 	Drop() error
 	IsUsed() bool
+	Stats() Stats
+}
+
+// Stats describes batches kept by the storage.
+type Stats struct {
+	Batches int
+	Bytes   int64
+	// Oldest is a time when the oldest batch was put, zero if storage is empty.
+	Oldest time.Time
 }
 
 // New constructs a new logs storage.
@@ -32,6 +41,8 @@ func New(path string) (Storage, error) {
 type MemoryStorage struct {
 	mu      sync.Mutex
 	storage [][]byte
+	times   []time.Time
+	size    int64
 }
 
 func newMemoryStorage() *MemoryStorage {
@@ -41,6 +52,8 @@ func newMemoryStorage() *MemoryStorage {
 func (s *MemoryStorage) Put(data []byte) error {
 	s.mu.Lock()
 	s.storage = append(s.storage, append(data[:0:0], data...))
+	s.times = append(s.times, time.Now())
+	s.size += int64(len(data))
 	s.mu.Unlock()
 
 	return nil
@@ -56,6 +69,8 @@ func (s *MemoryStorage) Pop() (b []byte, err error) {
 	}
 
 	b, s.storage = s.storage[0], s.storage[1:]
+	s.times = s.times[1:]
+	s.size -= int64(len(b))
 	s.mu.Unlock()
 
 	return b, nil
@@ -64,6 +79,8 @@ func (s *MemoryStorage) Pop() (b []byte, err error) {
 func (s *MemoryStorage) Drop() error {
 	s.mu.Lock()
 	s.storage = make([][]byte, 0)
+	s.times = nil
+	s.size = 0
 	s.mu.Unlock()
 
 	return nil
@@ -77,6 +94,21 @@ func (s *MemoryStorage) IsUsed() (ok bool) {
 	return ok
 }
 
+func (s *MemoryStorage) Stats() (stats Stats) {
+	s.mu.Lock()
+
+	stats.Batches = len(s.storage)
+	stats.Bytes = s.size
+
+	if len(s.times) > 0 {
+		stats.Oldest = s.times[0]
+	}
+
+	s.mu.Unlock()
+
+	return stats
+}
+
 type FileStorage struct {
 	dir   string
 	file  string
@@ -161,6 +193,26 @@ func (s *FileStorage) IsUsed() bool {
 	return atomic.LoadInt64(&s.count) > 0
 }
 
+// Stats reads the storage directory, so it's not intended for frequent calls.
+func (s *FileStorage) Stats() (stats Stats) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return stats
+	}
+
+	for _, file := range files {
+		if stats.Oldest.IsZero() || file.ModTime().Before(stats.Oldest) {
+			stats.Oldest = file.ModTime()
+		}
+
+		stats.Bytes += file.Size()
+	}
+
+	stats.Batches = len(files)
+
+	return stats
+}
+
 func (s *FileStorage) filename() string {
 	t := strconv.FormatInt(time.Now().UnixNano(), 10)
 
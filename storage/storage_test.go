@@ -85,6 +85,11 @@ func TestFileStorage(t *testing.T) {
 	assert.Equal(t, (int64)(3), storage.count)
 	assert.True(t, storage.IsUsed(), "expected is used")
 
+	stats := storage.Stats()
+	assert.Equal(t, 3, stats.Batches)
+	assert.Equal(t, int64(27), stats.Bytes)
+	assert.False(t, stats.Oldest.IsZero())
+
 	msg, err := storage.Pop()
 	if err != nil {
 		t.Fatal(err)
@@ -115,6 +120,7 @@ func TestFileStorage(t *testing.T) {
 	_, err = storage.Pop()
 	assert.EqualError(t, err, "no such data")
 	assert.False(t, storage.IsUsed(), "expected is not used")
+	assert.Equal(t, Stats{}, storage.Stats())
 
 	if err = storage.Drop(); err != nil {
 		t.Error(err)
@@ -168,6 +174,11 @@ func TestMemoryStorage(t *testing.T) {
 
 	assert.True(t, storage.IsUsed(), "expected is used")
 
+	stats := storage.Stats()
+	assert.Equal(t, 3, stats.Batches)
+	assert.Equal(t, int64(27), stats.Bytes)
+	assert.False(t, stats.Oldest.IsZero())
+
 	msg, err := storage.Pop()
 	if err != nil {
 		t.Fatal(err)
@@ -195,6 +206,7 @@ func TestMemoryStorage(t *testing.T) {
 	_, err = storage.Pop()
 	assert.EqualError(t, err, "no such data")
 	assert.False(t, storage.IsUsed(), "expected is not used")
+	assert.Equal(t, Stats{}, storage.Stats())
 
 	if err = storage.Drop(); err != nil {
 		t.Fatal(err)
@@ -6,11 +6,16 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/gadavy/elw/internal"
+	"github.com/gadavy/elw/storage"
+	"github.com/gadavy/elw/transport"
 )
 
 type MockTransport struct {
 	mock.Mock
 	isConnectedCounter int
+
+	ServerVersion transport.Version
+	NodeStatuses  []transport.NodeStatus
 }
 
 func (m *MockTransport) SendBulk(body []byte) error {
@@ -28,6 +33,18 @@ func (m *MockTransport) IsReconnected() <-chan struct{} {
 	return m.Called().Get(0).(<-chan struct{})
 }
 
+func (m *MockTransport) Version() transport.Version {
+	return m.ServerVersion
+}
+
+func (m *MockTransport) PutIndexTemplate(name string, body []byte) error {
+	return m.Called(name, body).Error(0)
+}
+
+func (m *MockTransport) Nodes() []transport.NodeStatus {
+	return m.NodeStatuses
+}
+
 type MockStorage struct {
 	mock.Mock
 
@@ -54,6 +71,10 @@ func (m *MockStorage) IsUsed() (ok bool) {
 	return ok
 }
 
+func (m *MockStorage) Stats() storage.Stats {
+	return m.Called().Get(0).(storage.Stats)
+}
+
 type MockLogger struct {
 	mock.Mock
 }
@@ -66,9 +87,12 @@ type StubTransport struct {
 	Ch internal.Signal
 }
 
-func (m *StubTransport) SendBulk([]byte) error          { return nil }
-func (m *StubTransport) IsConnected() bool              { return true }
-func (m *StubTransport) IsReconnected() <-chan struct{} { return m.Ch }
+func (m *StubTransport) SendBulk([]byte) error                 { return nil }
+func (m *StubTransport) IsConnected() bool                     { return true }
+func (m *StubTransport) IsReconnected() <-chan struct{}        { return m.Ch }
+func (m *StubTransport) Version() transport.Version            { return transport.Version{} }
+func (m *StubTransport) PutIndexTemplate(string, []byte) error { return nil }
+func (m *StubTransport) Nodes() []transport.NodeStatus         { return nil }
 
 type StubStorage struct{}
 
@@ -76,3 +100,4 @@ func (m *StubStorage) Put([]byte) error     { return nil }
 func (m *StubStorage) Pop() ([]byte, error) { return nil, nil }
 func (m *StubStorage) Drop() error          { return nil }
 func (m *StubStorage) IsUsed() bool         { return false }
+func (m *StubStorage) Stats() storage.Stats { return storage.Stats{} }
@@ -0,0 +1,73 @@
+package elw
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gadavy/elw/internal"
+)
+
+// documentTime returns time of the document used to resolve its index date.
+// It's taken from the first found timestamp field or the write time otherwise.
+func (w *ElasticWriter) documentTime(doc []byte) time.Time {
+	t := time.Now()
+
+	for _, field := range w.timestampFields {
+		value, ok := internal.LookupField(doc, field)
+		if !ok {
+			continue
+		}
+
+		if parsed, ok := parseTimestamp(value); ok {
+			t = parsed
+
+			break
+		}
+	}
+
+	if w.location != nil {
+		t = t.In(w.location)
+	}
+
+	return t
+}
+
+// parseTimestamp parses raw JSON value as RFC3339 string or epoch number
+// in seconds, milliseconds, microseconds or nanoseconds.
+func parseTimestamp(value []byte) (time.Time, bool) {
+	if len(value) == 0 {
+		return time.Time{}, false
+	}
+
+	if value[0] == '"' {
+		s, ok := internal.Unquote(value)
+		if !ok {
+			return time.Time{}, false
+		}
+
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t, true
+		}
+
+		value = []byte(s)
+	}
+
+	epoch, err := strconv.ParseFloat(string(value), 64)
+	if err != nil || epoch <= 0 {
+		return time.Time{}, false
+	}
+
+	switch {
+	case epoch < 1e11:
+		sec, frac := math.Modf(epoch)
+
+		return time.Unix(int64(sec), int64(frac*1e9)), true
+	case epoch < 1e14:
+		return time.Unix(0, int64(epoch*1e6)), true
+	case epoch < 1e17:
+		return time.Unix(0, int64(epoch*1e3)), true
+	default:
+		return time.Unix(0, int64(epoch)), true
+	}
+}
@@ -0,0 +1,71 @@
+package elw
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	expected := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		ok    bool
+	}{
+		{name: "RFC3339", value: `"2020-01-02T03:04:05Z"`, ok: true},
+		{name: "RFC3339Nano", value: `"2020-01-02T06:04:05.000+03:00"`, ok: true},
+		{name: "Seconds", value: `1577934245`, ok: true},
+		{name: "FloatSeconds", value: `1577934245.0`, ok: true},
+		{name: "Milliseconds", value: `1577934245000`, ok: true},
+		{name: "Microseconds", value: `1577934245000000`, ok: true},
+		{name: "Nanoseconds", value: `1577934245000000000`, ok: true},
+		{name: "QuotedEpoch", value: `"1577934245"`, ok: true},
+		{name: "InvalidString", value: `"yesterday"`, ok: false},
+		{name: "Object", value: `{}`, ok: false},
+		{name: "Negative", value: `-1`, ok: false},
+		{name: "Empty", value: ``, ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, ok := parseTimestamp([]byte(tt.value))
+
+			assert.Equal(t, tt.ok, ok)
+
+			if tt.ok {
+				assert.True(t, expected.Equal(res), "expected %s, got %s", expected, res)
+			}
+		})
+	}
+}
+
+func TestElasticWriter_documentTime(t *testing.T) {
+	location := time.FixedZone("UTC+5", 5*60*60)
+
+	writer := ElasticWriter{
+		timestampFields: []string{"@timestamp", "time"},
+		location:        location,
+	}
+
+	t.Run("FirstField", func(t *testing.T) {
+		res := writer.documentTime([]byte(`{"time":1,"@timestamp":"2020-01-01T22:00:00Z"}`))
+
+		assert.Equal(t, "2020-01-02T03:00:00+05:00", res.Format(time.RFC3339))
+	})
+
+	t.Run("SecondField", func(t *testing.T) {
+		res := writer.documentTime([]byte(`{"@timestamp":"invalid","time":1577934245}`))
+
+		assert.Equal(t, "2020-01-02T08:04:05+05:00", res.Format(time.RFC3339))
+	})
+
+	t.Run("WriteTime", func(t *testing.T) {
+		before := time.Now()
+		res := writer.documentTime([]byte(`{"message":"test"}`))
+
+		assert.False(t, res.Before(before))
+		assert.Equal(t, location, res.Location())
+	})
+}
@@ -0,0 +1,104 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// BulkItem is a result of a single bulk action.
+// Full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/current/docs-bulk.html#bulk-api-response-body
+type BulkItem struct {
+	Index  string          `json:"_index"`
+	ID     string          `json:"_id"`
+	Status int             `json:"status"`
+	Error  *BulkItemReason `json:"error,omitempty"`
+}
+
+// BulkItemReason describes why a bulk action failed.
+type BulkItemReason struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+// FailedItem is a bulk action rejected by Elasticsearch.
+type FailedItem struct {
+	// Position of the action in the request body, starting from zero.
+	Position int
+	// Action name: index, create, update or delete.
+	Action string
+
+	BulkItem
+}
+
+// IsRetriable reports whether the action can be sent again later.
+func (i FailedItem) IsRetriable() bool {
+	return i.Status == http.StatusTooManyRequests || i.Status == http.StatusServiceUnavailable
+}
+
+// BulkError returned when the bulk request was accepted,
+// but some of its actions were rejected.
+type BulkError struct {
+	Items []FailedItem
+}
+
+func (e *BulkError) Error() string {
+	var retriable int
+
+	for _, item := range e.Items {
+		if item.IsRetriable() {
+			retriable++
+		}
+	}
+
+	return fmt.Sprintf("bulk request: %d actions failed, %d of them retriable", len(e.Items), retriable)
+}
+
+// ParseBulkResponse decodes response body and returns BulkError if any action failed.
+func ParseBulkResponse(body []byte) error {
+	// the most of responses has no errors, so check it before decoding items.
+	var head struct {
+		Errors bool            `json:"errors"`
+		Items  json.RawMessage `json:"items"`
+	}
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(body, &head); err != nil || !head.Errors {
+		return nil
+	}
+
+	var items []map[string]BulkItem
+
+	if err := json.Unmarshal(head.Items, &items); err != nil {
+		return nil
+	}
+
+	bulkErr := &BulkError{}
+
+	for pos, item := range items {
+		for action, result := range item {
+			if result.Error == nil && result.Status < http.StatusMultipleChoices {
+				continue
+			}
+
+			if result.Error == nil {
+				result.Error = &BulkItemReason{Type: http.StatusText(result.Status)}
+			}
+
+			bulkErr.Items = append(bulkErr.Items, FailedItem{
+				Position: pos,
+				Action:   action,
+				BulkItem: result,
+			})
+		}
+	}
+
+	if len(bulkErr.Items) == 0 {
+		return nil
+	}
+
+	return bulkErr
+}
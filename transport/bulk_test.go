@@ -0,0 +1,91 @@
+package transport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBulkResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		expectedErr error
+	}{
+		{
+			name:        "EmptyBody",
+			body:        "",
+			expectedErr: nil,
+		},
+		{
+			name:        "InvalidBody",
+			body:        "not a json",
+			expectedErr: nil,
+		},
+		{
+			name:        "NoErrors",
+			body:        `{"took":3,"errors":false,"items":[{"index":{"_index":"test","status":201}}]}`,
+			expectedErr: nil,
+		},
+		{
+			name: "Errors",
+			body: `{"took":3,"errors":true,"items":[` +
+				`{"index":{"_index":"test","_id":"1","status":201}},` +
+				`{"index":{"_index":"test","_id":"2","status":429,"error":{"type":"es_rejected_execution_exception","reason":"rejected"}}},` +
+				`{"create":{"_index":"test","_id":"3","status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}},` +
+				`{"delete":{"_index":"test","_id":"4","status":404}}]}`,
+			expectedErr: &BulkError{Items: []FailedItem{
+				{
+					Position: 1,
+					Action:   "index",
+					BulkItem: BulkItem{
+						Index:  "test",
+						ID:     "2",
+						Status: 429,
+						Error:  &BulkItemReason{Type: "es_rejected_execution_exception", Reason: "rejected"},
+					},
+				},
+				{
+					Position: 2,
+					Action:   "create",
+					BulkItem: BulkItem{
+						Index:  "test",
+						ID:     "3",
+						Status: 400,
+						Error:  &BulkItemReason{Type: "mapper_parsing_exception", Reason: "failed to parse"},
+					},
+				},
+				{
+					Position: 3,
+					Action:   "delete",
+					BulkItem: BulkItem{
+						Index:  "test",
+						ID:     "4",
+						Status: 404,
+						Error:  &BulkItemReason{Type: "Not Found"},
+					},
+				},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedErr, ParseBulkResponse([]byte(tt.body)))
+		})
+	}
+}
+
+func TestFailedItem_IsRetriable(t *testing.T) {
+	assert.True(t, FailedItem{BulkItem: BulkItem{Status: 429}}.IsRetriable())
+	assert.True(t, FailedItem{BulkItem: BulkItem{Status: 503}}.IsRetriable())
+	assert.False(t, FailedItem{BulkItem: BulkItem{Status: 400}}.IsRetriable())
+}
+
+func TestBulkError_Error(t *testing.T) {
+	err := &BulkError{Items: []FailedItem{
+		{BulkItem: BulkItem{Status: 429}},
+		{BulkItem: BulkItem{Status: 400}},
+	}}
+
+	assert.EqualError(t, err, "bulk request: 2 actions failed, 1 of them retriable")
+}
@@ -1,6 +1,7 @@
 package transport
 
 import (
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -44,10 +45,15 @@ func NewNodeClient(url, useragent string) *NodeClient {
 
 // Bulk request allows to perform multiple index operations in a single request.
 // Full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/current/docs-bulk.html
-func (c *NodeClient) BulkRequest(body []byte, timeout time.Duration) (code int, err error) {
+// Response body is returned only for successful requests. Query is appended to the request URI
+// if not empty. If compatibleWith isn't zero, compatibility headers are sent to request REST API
+// of that major version.
+func (c *NodeClient) BulkRequest(body []byte, query string, timeout time.Duration, compatibleWith int) (code int, respBody []byte, err error) {
 	const (
-		contentType = "application/x-ndjson"
-		requestURI  = "/_bulk"
+		contentType           = "application/x-ndjson"
+		compatibleContentType = "application/vnd.elasticsearch+x-ndjson;compatible-with="
+		compatibleAccept      = "application/vnd.elasticsearch+json;compatible-with="
+		requestURI            = "/_bulk"
 	)
 
 	req := fasthttp.AcquireRequest()
@@ -62,13 +68,26 @@ func (c *NodeClient) BulkRequest(body []byte, timeout time.Duration) (code int,
 	req.Header.SetRequestURI(requestURI)
 	req.Header.SetHost(c.host)
 
+	if query != "" {
+		req.Header.SetRequestURI(requestURI + "?" + query)
+	}
+
+	if compatibleWith > 0 {
+		version := strconv.Itoa(compatibleWith)
+
+		req.Header.SetContentType(compatibleContentType + version)
+		req.Header.Set(fasthttp.HeaderAccept, compatibleAccept+version)
+	}
+
 	req.SetBody(body)
 
 	atomic.StoreInt64(&c.lastUseTime, time.Now().UnixNano())
 
-	err = c.client.DoTimeout(req, resp, timeout)
+	if err = c.client.DoTimeout(req, resp, timeout); err == nil {
+		respBody = append(respBody, resp.Body()...)
+	}
 
-	return resp.StatusCode(), err
+	return resp.StatusCode(), respBody, err
 }
 
 // Ping request allows to check connection status.
@@ -93,6 +112,69 @@ func (c *NodeClient) PingRequest(timeout time.Duration) (code int, err error) {
 	return resp.StatusCode(), err
 }
 
+// Info request returns basic information about the cluster, including its version.
+// Response body is returned only for successful requests.
+func (c *NodeClient) InfoRequest(timeout time.Duration) (code int, respBody []byte, err error) {
+	const requestURI = "/"
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.Header.SetMethod(fasthttp.MethodGet)
+	req.Header.SetUserAgent(c.useragent)
+	req.Header.SetRequestURI(requestURI)
+	req.Header.SetHost(c.host)
+
+	atomic.StoreInt64(&c.lastUseTime, time.Now().UnixNano())
+
+	if err = c.client.DoTimeout(req, resp, timeout); err == nil {
+		respBody = append(respBody, resp.Body()...)
+	}
+
+	return resp.StatusCode(), respBody, err
+}
+
+// Request performs arbitrary request with JSON body, e.g. to manage index templates.
+// Response body is returned only for successful requests.
+func (c *NodeClient) Request(method, requestURI string, body []byte, timeout time.Duration) (code int, respBody []byte, err error) {
+	const contentType = "application/json"
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.Header.SetMethod(method)
+	req.Header.SetUserAgent(c.useragent)
+	req.Header.SetContentType(contentType)
+	req.Header.SetRequestURI(requestURI)
+	req.Header.SetHost(c.host)
+
+	req.SetBody(body)
+
+	atomic.StoreInt64(&c.lastUseTime, time.Now().UnixNano())
+
+	if err = c.client.DoTimeout(req, resp, timeout); err == nil {
+		respBody = append(respBody, resp.Body()...)
+	}
+
+	return resp.StatusCode(), respBody, err
+}
+
+// Host returns URL of the node.
+func (c *NodeClient) Host() string {
+	return c.host
+}
+
+// IsLive reports whether the node is available for requests.
+func (c *NodeClient) IsLive() bool {
+	return atomic.LoadUint32(&c.status) == isLive
+}
+
 // PendingRequests returns all pending request of node client.
 func (c *NodeClient) PendingRequests() int {
 	return c.client.PendingRequests()
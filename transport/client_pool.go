@@ -8,8 +8,12 @@ import (
 type ClientsPool interface {
 	NextLive() (*NodeClient, error)
 	NextDead() (*NodeClient, error)
-	OnFailure(c *NodeClient)
-	OnSuccess(c *NodeClient)
+	// OnFailure marks client as dead, returns false if it's already dead.
+	OnFailure(c *NodeClient) bool
+	// OnSuccess marks client as live, returns false if it's already live.
+	OnSuccess(c *NodeClient) bool
+	// Clients returns all clients of the pool.
+	Clients() []*NodeClient
 }
 
 func NewClientsPool(urls []string, useragent string) (ClientsPool, error) {
@@ -54,12 +58,16 @@ func (p *SinglePool) NextDead() (*NodeClient, error) {
 	return p.client, nil
 }
 
-func (p *SinglePool) OnFailure(c *NodeClient) {
-	atomic.StoreUint32(&c.status, isDead)
+func (p *SinglePool) OnFailure(c *NodeClient) bool {
+	return atomic.CompareAndSwapUint32(&c.status, isLive, isDead)
 }
 
-func (p *SinglePool) OnSuccess(c *NodeClient) {
-	atomic.StoreUint32(&c.status, isLive)
+func (p *SinglePool) OnSuccess(c *NodeClient) bool {
+	return atomic.CompareAndSwapUint32(&c.status, isDead, isLive)
+}
+
+func (p *SinglePool) Clients() []*NodeClient {
+	return []*NodeClient{p.client}
 }
 
 type ClusterPool struct {
@@ -74,12 +82,16 @@ func (p *ClusterPool) NextDead() (*NodeClient, error) {
 	return p.next(isDead)
 }
 
-func (p *ClusterPool) OnFailure(c *NodeClient) {
-	atomic.StoreUint32(&c.status, isDead)
+func (p *ClusterPool) OnFailure(c *NodeClient) bool {
+	return atomic.CompareAndSwapUint32(&c.status, isLive, isDead)
+}
+
+func (p *ClusterPool) OnSuccess(c *NodeClient) bool {
+	return atomic.CompareAndSwapUint32(&c.status, isDead, isLive)
 }
 
-func (p *ClusterPool) OnSuccess(c *NodeClient) {
-	atomic.StoreUint32(&c.status, isLive)
+func (p *ClusterPool) Clients() []*NodeClient {
+	return p.clients
 }
 
 // https://groups.google.com/group/golang-nuts/msg/71c307e4d73024ce?pli=1
@@ -17,13 +17,15 @@ func TestNodeClient_BulkRequest(t *testing.T) {
 	)
 
 	tests := []struct {
-		name         string
-		handler      func(t *testing.T) fasthttp.RequestHandler
-		body         []byte
-		timeout      time.Duration
-		wantErr      bool
-		expectedCode int
-		expectedErr  error
+		name           string
+		handler        func(t *testing.T) fasthttp.RequestHandler
+		body           []byte
+		query          string
+		timeout        time.Duration
+		compatibleWith int
+		wantErr        bool
+		expectedCode   int
+		expectedErr    error
 	}{
 		{
 			name: "pass",
@@ -44,6 +46,42 @@ func TestNodeClient_BulkRequest(t *testing.T) {
 			wantErr:      false,
 			expectedCode: 200,
 		},
+		{
+			name: "compatibility headers",
+			handler: func(t *testing.T) fasthttp.RequestHandler {
+				return func(ctx *fasthttp.RequestCtx) {
+					assert.Equal(t, string(ctx.Path()), "/_bulk")
+					assert.Equal(t, string(ctx.Request.Header.ContentType()),
+						"application/vnd.elasticsearch+x-ndjson;compatible-with=7")
+					assert.Equal(t, string(ctx.Request.Header.Peek("Accept")),
+						"application/vnd.elasticsearch+json;compatible-with=7")
+
+					ctx.Response.Header.SetStatusCode(200)
+				}
+			},
+			body:           []byte("BulkRequest"),
+			timeout:        time.Second,
+			compatibleWith: 7,
+			wantErr:        false,
+			expectedCode:   200,
+		},
+		{
+			name: "query",
+			handler: func(t *testing.T) fasthttp.RequestHandler {
+				return func(ctx *fasthttp.RequestCtx) {
+					assert.Equal(t, string(ctx.Path()), "/_bulk")
+					assert.Equal(t, string(ctx.QueryArgs().Peek("pipeline")), "logs")
+					assert.Equal(t, string(ctx.QueryArgs().Peek("refresh")), "false")
+
+					ctx.Response.Header.SetStatusCode(200)
+				}
+			},
+			body:         []byte("BulkRequest"),
+			query:        "pipeline=logs&refresh=false",
+			timeout:      time.Second,
+			wantErr:      false,
+			expectedCode: 200,
+		},
 		{
 			name: "timeout",
 			handler: func(t *testing.T) fasthttp.RequestHandler {
@@ -98,7 +136,7 @@ func TestNodeClient_BulkRequest(t *testing.T) {
 			client := NewNodeClient(host, useragent)
 			client.client.Dial = func(addr string) (conn net.Conn, err error) { return listener.Dial() }
 
-			code, err := client.BulkRequest(tt.body, tt.timeout)
+			code, _, err := client.BulkRequest(tt.body, tt.query, tt.timeout, tt.compatibleWith)
 			if (err != nil) != tt.wantErr {
 				t.Error(err)
 			}
@@ -218,6 +256,45 @@ func TestNodeClient_PingRequest(t *testing.T) {
 	}
 }
 
+func TestNodeClient_InfoRequest(t *testing.T) {
+	const (
+		host      = "http://127.0.0.1:8080"
+		useragent = "test-client"
+		info      = `{"version":{"number":"7.10.2"}}`
+	)
+
+	var (
+		listener = fasthttputil.NewInmemoryListener()
+		server   = fasthttp.Server{}
+	)
+
+	server.Handler = func(ctx *fasthttp.RequestCtx) {
+		assert.Equal(t, string(ctx.Request.Host()), host)
+		assert.Equal(t, string(ctx.Method()), "GET")
+		assert.Equal(t, string(ctx.Path()), "/")
+		assert.Equal(t, string(ctx.UserAgent()), useragent)
+
+		ctx.Response.Header.SetStatusCode(200)
+		ctx.Response.SetBodyString(info)
+	}
+
+	go server.Serve(listener)
+
+	client := NewNodeClient(host, useragent)
+	client.client.Dial = func(addr string) (conn net.Conn, err error) { return listener.Dial() }
+
+	code, body, err := client.InfoRequest(time.Second)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, 200, code)
+	assert.Equal(t, info, string(body))
+
+	listener.Close()
+	server.Shutdown()
+}
+
 func TestNodeClient_LastUseTime(t *testing.T) {
 	const (
 		host      = "http://127.0.0.1:8080"
@@ -0,0 +1,44 @@
+package transport
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// BulkParams are query parameters of bulk requests, empty values are omitted.
+// Full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/current/docs-bulk.html#docs-bulk-api-query-params
+type BulkParams struct {
+	// Pipeline is a default ingest pipeline of documents.
+	Pipeline string
+	// Refresh is true, false or wait_for.
+	Refresh string
+	// Timeout is a period each action waits for automatic index creation,
+	// dynamic mapping updates and active shards.
+	Timeout time.Duration
+	// WaitForActiveShards is a number of shard copies or all.
+	WaitForActiveShards string
+}
+
+// Query returns URL encoded parameters.
+func (p BulkParams) Query() string {
+	values := make(url.Values)
+
+	if p.Pipeline != "" {
+		values.Set("pipeline", p.Pipeline)
+	}
+
+	if p.Refresh != "" {
+		values.Set("refresh", p.Refresh)
+	}
+
+	if p.Timeout > 0 {
+		values.Set("timeout", strconv.FormatInt(int64(p.Timeout/time.Millisecond), 10)+"ms")
+	}
+
+	if p.WaitForActiveShards != "" {
+		values.Set("wait_for_active_shards", p.WaitForActiveShards)
+	}
+
+	return values.Encode()
+}
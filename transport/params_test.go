@@ -0,0 +1,37 @@
+package transport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkParams_Query(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   BulkParams
+		expected string
+	}{
+		{
+			name:     "Empty",
+			params:   BulkParams{},
+			expected: "",
+		},
+		{
+			name: "All",
+			params: BulkParams{
+				Pipeline:            "logs",
+				Refresh:             "wait_for",
+				Timeout:             1500 * time.Millisecond,
+				WaitForActiveShards: "all",
+			},
+			expected: "pipeline=logs&refresh=wait_for&timeout=1500ms&wait_for_active_shards=all",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.params.Query())
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package transport
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
 	"sync/atomic"
 	"time"
 
@@ -10,9 +13,69 @@ import (
 )
 
 type Transport interface {
+	// SendBulk returns *BulkError if request was accepted, but some actions were rejected,
+	// and ErrRequestTooLarge if request body exceeds http.max_content_length of the server.
 	SendBulk(body []byte) error
 	IsConnected() bool
 	IsReconnected() <-chan struct{}
+	// Version returns server version, detected on the first successful ping.
+	Version() Version
+	// PutIndexTemplate creates or updates composable index template.
+	PutIndexTemplate(name string, body []byte) error
+	// Nodes returns status of all nodes.
+	Nodes() []NodeStatus
+}
+
+// NodeStatus describes availability of the node.
+type NodeStatus struct {
+	Host string
+	Live bool
+}
+
+// Hooks receives events of nodes, methods must be safe for concurrent use.
+type Hooks interface {
+	// OnNodeDown is called when the node fails.
+	OnNodeDown(host string, err error)
+	// OnNodeUp is called when the failed node responds to ping.
+	OnNodeUp(host string)
+	// OnDisconnected is called when all nodes are failed.
+	OnDisconnected()
+	// OnReconnected is called when any node is up after disconnection.
+	OnReconnected()
+}
+
+// BulkResult describes a single bulk request to the node.
+type BulkResult struct {
+	Node string
+	// StatusCode is zero if request failed.
+	StatusCode int
+	// Size is a size of the request body.
+	Size     int
+	Duration time.Duration
+	Err      error
+}
+
+// BulkObserver is called after each bulk request, it must be safe for concurrent use.
+type BulkObserver func(r BulkResult)
+
+var (
+	ErrRequestTooLarge = errors.New("request entity too large")
+)
+
+// StatusError is returned when the node responds with unexpected status code.
+type StatusError struct {
+	Request    string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: unexpected status code %d: %s", e.Request, e.StatusCode, e.Body)
+}
+
+// IsRetriable reports whether the request can be sent again later.
+func (e *StatusError) IsRetriable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
 }
 
 type Config struct {
@@ -21,6 +84,18 @@ type Config struct {
 	PingInterval   time.Duration
 	SuccessCodes   []int
 	UserAgent      string
+
+	// Version disables detection of the server version if set.
+	Version Version
+	// CompatibilityMode enables compatibility headers for Elasticsearch 8.x,
+	// requesting REST API of 7.x.
+	CompatibilityMode bool
+	// OnBulk is called after each bulk request if set.
+	OnBulk BulkObserver
+	// Hooks receives events of nodes if set.
+	Hooks Hooks
+	// BulkParams are query parameters of bulk requests.
+	BulkParams BulkParams
 }
 
 type httpTransport struct {
@@ -31,6 +106,12 @@ type httpTransport struct {
 	pingInterval   time.Duration
 	successCodes   map[int]bool
 
+	version           atomic.Value
+	compatibilityMode bool
+	onBulk            BulkObserver
+	hooks             Hooks
+	bulkQuery         string
+
 	deadSignal internal.Signal
 	liveSignal internal.Signal
 }
@@ -48,6 +129,11 @@ func New(cfg Config) (Transport, error) {
 		requestTimeout: cfg.RequestTimeout,
 		successCodes:   make(map[int]bool),
 
+		compatibilityMode: cfg.CompatibilityMode,
+		onBulk:            cfg.OnBulk,
+		hooks:             cfg.Hooks,
+		bulkQuery:         cfg.BulkParams.Query(),
+
 		liveSignal: make(internal.Signal, 1),
 		deadSignal: make(internal.Signal, 1),
 	}
@@ -56,6 +142,10 @@ func New(cfg Config) (Transport, error) {
 		transport.successCodes[code] = true
 	}
 
+	if !cfg.Version.IsUnknown() {
+		transport.version.Store(cfg.Version)
+	}
+
 	go transport.pingDeadNodes()
 
 	return transport, nil
@@ -69,54 +159,138 @@ func (t *httpTransport) IsReconnected() <-chan struct{} {
 	return t.liveSignal
 }
 
+func (t *httpTransport) Version() Version {
+	v, _ := t.version.Load().(Version)
+
+	return v
+}
+
 func (t *httpTransport) SendBulk(body []byte) error {
 	var (
-		client *NodeClient
-		code   int
-		err    error
+		client   *NodeClient
+		code     int
+		respBody []byte
+		err      error
 	)
 
 	for {
 		client, err = t.clientsPool.NextLive()
 		if err != nil {
-			atomic.StoreUint32(&t.connStatus, isDead)
+			if atomic.CompareAndSwapUint32(&t.connStatus, isLive, isDead) && t.hooks != nil {
+				t.hooks.OnDisconnected()
+			}
 
 			t.deadSignal.Send()
 
 			return err
 		}
 
-		code, err = client.BulkRequest(body, t.requestTimeout)
+		start := time.Now()
+
+		code, respBody, err = client.BulkRequest(body, t.bulkQuery, t.requestTimeout, t.compatibleWith())
+
+		if t.onBulk != nil {
+			t.observeBulk(client, code, len(body), time.Since(start), err)
+		}
 		if err == nil && t.successCodes[code] {
-			return nil
+			return ParseBulkResponse(respBody)
+		}
+
+		// node is alive, so the request is not sent to other nodes.
+		if err == nil && code == fasthttp.StatusRequestEntityTooLarge {
+			return ErrRequestTooLarge
 		}
 
 		if err != fasthttp.ErrNoFreeConns {
-			t.clientsPool.OnFailure(client)
+			if t.clientsPool.OnFailure(client) && t.hooks != nil {
+				if err == nil {
+					err = fmt.Errorf("unexpected status code %d", code)
+				}
+
+				t.hooks.OnNodeDown(client.Host(), err)
+			}
+
 			t.deadSignal.Send()
 		}
 	}
 }
 
+func (t *httpTransport) observeBulk(client *NodeClient, code, size int, duration time.Duration, err error) {
+	if err != nil {
+		code = 0
+	}
+
+	t.onBulk(BulkResult{
+		Node:       client.Host(),
+		StatusCode: code,
+		Size:       size,
+		Duration:   duration,
+		Err:        err,
+	})
+}
+
+func (t *httpTransport) Nodes() []NodeStatus {
+	clients := t.clientsPool.Clients()
+	nodes := make([]NodeStatus, 0, len(clients))
+
+	for _, client := range clients {
+		nodes = append(nodes, NodeStatus{Host: client.Host(), Live: client.IsLive()})
+	}
+
+	return nodes
+}
+
+func (t *httpTransport) PutIndexTemplate(name string, body []byte) error {
+	client, err := t.clientsPool.NextLive()
+	if err != nil {
+		return err
+	}
+
+	code, respBody, err := client.Request(fasthttp.MethodPut, "/_index_template/"+name, body, t.requestTimeout)
+	if err != nil {
+		return err
+	}
+
+	if !t.successCodes[code] {
+		return &StatusError{
+			Request:    "put index template " + name,
+			StatusCode: code,
+			Body:       append([]byte(nil), respBody...),
+		}
+	}
+
+	return nil
+}
+
+func (t *httpTransport) compatibleWith() int {
+	if !t.compatibilityMode {
+		return 0
+	}
+
+	return t.Version().CompatibleWith()
+}
+
 func (t *httpTransport) pingDeadNodes() {
 	var (
 		client *NodeClient
-		code   int
 		err    error
 	)
 
 	for {
 		client, err = t.clientsPool.NextDead()
 		if err != nil {
-			<-t.deadSignal
+			t.waitDeadNodes()
 			continue
 		}
 
-		code, err = client.PingRequest(t.requestTimeout)
-		if err == nil && t.successCodes[code] {
-			t.clientsPool.OnSuccess(client)
+		if t.ping(client) {
+			if t.clientsPool.OnSuccess(client) && t.hooks != nil {
+				t.hooks.OnNodeUp(client.Host())
+			}
 
-			atomic.StoreUint32(&t.connStatus, isLive)
+			if atomic.CompareAndSwapUint32(&t.connStatus, isDead, isLive) && t.hooks != nil {
+				t.hooks.OnReconnected()
+			}
 
 			t.liveSignal.Send()
 		}
@@ -124,3 +298,45 @@ func (t *httpTransport) pingDeadNodes() {
 		time.Sleep(t.pingInterval)
 	}
 }
+
+// waitDeadNodes blocks until any node fails. Until the server version is known,
+// live nodes are requested for it every ping interval.
+func (t *httpTransport) waitDeadNodes() {
+	if !t.Version().IsUnknown() {
+		<-t.deadSignal
+
+		return
+	}
+
+	if client, err := t.clientsPool.NextLive(); err == nil && t.ping(client) && !t.Version().IsUnknown() {
+		return
+	}
+
+	timer := time.NewTimer(t.pingInterval)
+	defer timer.Stop()
+
+	select {
+	case <-t.deadSignal:
+	case <-timer.C:
+	}
+}
+
+// ping checks the node, requesting server version if it's unknown yet.
+func (t *httpTransport) ping(client *NodeClient) bool {
+	if !t.Version().IsUnknown() {
+		code, err := client.PingRequest(t.requestTimeout)
+
+		return err == nil && t.successCodes[code]
+	}
+
+	code, body, err := client.InfoRequest(t.requestTimeout)
+	if err != nil || !t.successCodes[code] {
+		return false
+	}
+
+	if v, ok := ParseVersion(body); ok {
+		t.version.Store(v)
+	}
+
+	return true
+}
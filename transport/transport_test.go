@@ -2,6 +2,7 @@ package transport
 
 import (
 	"net"
+	"sync"
 	"testing"
 	"time"
 
@@ -216,6 +217,61 @@ func TestHttpTransport_SendBulk(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:  "RequestItemsFailed",
+			input: []byte("bulk"),
+			transport: &httpTransport{
+				requestTimeout: time.Second,
+				pingInterval:   time.Second,
+				successCodes:   map[int]bool{200: true},
+				deadSignal:     make(internal.Signal, 1),
+				liveSignal:     make(internal.Signal, 1),
+			},
+			client: &NodeClient{
+				host:      host,
+				useragent: useragent,
+				status:    isLive,
+				client: fasthttp.HostClient{
+					Addr:     "127.0.0.1:8080",
+					MaxConns: 1,
+				},
+			},
+			handler: func(t *testing.T) fasthttp.RequestHandler {
+				return func(ctx *fasthttp.RequestCtx) {
+					ctx.Response.SetStatusCode(200)
+					ctx.Response.SetBodyString(`{"errors":true,"items":[{"index":{"status":429}}]}`)
+				}
+			},
+			wantErr:     true,
+			expectedErr: "bulk request: 1 actions failed, 1 of them retriable",
+		},
+		{
+			name:  "RequestTooLarge",
+			input: []byte("bulk"),
+			transport: &httpTransport{
+				requestTimeout: time.Second,
+				pingInterval:   time.Second,
+				successCodes:   map[int]bool{200: true},
+				deadSignal:     make(internal.Signal, 1),
+				liveSignal:     make(internal.Signal, 1),
+			},
+			client: &NodeClient{
+				host:      host,
+				useragent: useragent,
+				status:    isLive,
+				client: fasthttp.HostClient{
+					Addr:     "127.0.0.1:8080",
+					MaxConns: 1,
+				},
+			},
+			handler: func(t *testing.T) fasthttp.RequestHandler {
+				return func(ctx *fasthttp.RequestCtx) {
+					ctx.Response.SetStatusCode(413)
+				}
+			},
+			wantErr:     true,
+			expectedErr: ErrRequestTooLarge.Error(),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -351,3 +407,243 @@ func TestHttpTransport_pingDeadNodes(t *testing.T) {
 		})
 	}
 }
+
+func TestHttpTransport_detectVersion(t *testing.T) {
+	const (
+		host      = "http://127.0.0.1:8080"
+		useragent = "test-client"
+	)
+
+	var (
+		listener = fasthttputil.NewInmemoryListener()
+		server   = fasthttp.Server{}
+	)
+
+	server.Handler = func(ctx *fasthttp.RequestCtx) {
+		assert.Equal(t, string(ctx.Method()), "GET")
+
+		ctx.SetStatusCode(200)
+		ctx.SetBodyString(`{"version":{"number":"8.0.0"}}`)
+	}
+
+	go server.Serve(listener)
+
+	client := NewNodeClient(host, useragent)
+	client.client.Dial = func(addr string) (conn net.Conn, err error) { return listener.Dial() }
+
+	transport := &httpTransport{
+		clientsPool:       &SinglePool{client: client},
+		requestTimeout:    time.Second,
+		pingInterval:      time.Second,
+		successCodes:      map[int]bool{200: true},
+		compatibilityMode: true,
+		deadSignal:        make(internal.Signal, 1),
+		liveSignal:        make(internal.Signal, 1),
+	}
+
+	assert.True(t, transport.Version().IsUnknown())
+	assert.Equal(t, 0, transport.compatibleWith())
+
+	transport.waitDeadNodes()
+
+	assert.Equal(t, Version{Distribution: Elasticsearch, Major: 8}, transport.Version())
+	assert.Equal(t, 7, transport.compatibleWith())
+
+	listener.Close()
+	server.Shutdown()
+}
+
+func TestHttpTransport_PutIndexTemplate(t *testing.T) {
+	const (
+		host      = "http://127.0.0.1:8080"
+		useragent = "test-client"
+	)
+
+	tests := []struct {
+		name        string
+		code        int
+		wantErr     bool
+		expectedErr string
+	}{
+		{
+			name: "Pass",
+			code: 200,
+		},
+		{
+			name:        "BadRequest",
+			code:        400,
+			wantErr:     true,
+			expectedErr: "put index template logs: unexpected status code 400: error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				listener = fasthttputil.NewInmemoryListener()
+				server   = fasthttp.Server{}
+			)
+
+			server.Handler = func(ctx *fasthttp.RequestCtx) {
+				assert.Equal(t, string(ctx.Method()), "PUT")
+				assert.Equal(t, string(ctx.Path()), "/_index_template/logs")
+				assert.Equal(t, string(ctx.Request.Header.ContentType()), "application/json")
+				assert.Equal(t, string(ctx.Request.Body()), "{}")
+
+				ctx.SetStatusCode(tt.code)
+
+				if tt.wantErr {
+					ctx.SetBodyString("error")
+				}
+			}
+
+			go server.Serve(listener)
+
+			client := NewNodeClient(host, useragent)
+			client.client.Dial = func(addr string) (conn net.Conn, err error) { return listener.Dial() }
+
+			transport := &httpTransport{
+				clientsPool:    &SinglePool{client: client},
+				requestTimeout: time.Second,
+				successCodes:   map[int]bool{200: true},
+			}
+
+			err := transport.PutIndexTemplate("logs", []byte("{}"))
+			if (err != nil) != tt.wantErr {
+				t.Error(err)
+			}
+
+			if tt.wantErr {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.False(t, err.(*StatusError).IsRetriable())
+			}
+
+			listener.Close()
+			server.Shutdown()
+		})
+	}
+}
+
+func TestHttpTransport_OnBulk(t *testing.T) {
+	const (
+		host      = "http://127.0.0.1:8080"
+		useragent = "test-client"
+	)
+
+	var (
+		listener = fasthttputil.NewInmemoryListener()
+		server   = fasthttp.Server{}
+		results  []BulkResult
+	)
+
+	server.Handler = func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(200)
+	}
+
+	go server.Serve(listener)
+
+	client := NewNodeClient(host, useragent)
+	client.client.Dial = func(addr string) (conn net.Conn, err error) { return listener.Dial() }
+
+	transport := &httpTransport{
+		clientsPool:    &SinglePool{client: client},
+		requestTimeout: time.Second,
+		successCodes:   map[int]bool{200: true},
+		onBulk: func(r BulkResult) {
+			results = append(results, r)
+		},
+	}
+
+	assert.NoError(t, transport.SendBulk([]byte("bulk")))
+
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, host, results[0].Node)
+		assert.Equal(t, 200, results[0].StatusCode)
+		assert.Equal(t, 4, results[0].Size)
+		assert.NoError(t, results[0].Err)
+	}
+
+	assert.Equal(t, []NodeStatus{{Host: host, Live: true}}, transport.Nodes())
+
+	listener.Close()
+	server.Shutdown()
+}
+
+type recordingHooks struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (h *recordingHooks) record(event string) {
+	h.mu.Lock()
+	h.events = append(h.events, event)
+	h.mu.Unlock()
+}
+
+func (h *recordingHooks) OnNodeDown(host string, err error) {
+	h.record("down " + host + ": " + err.Error())
+}
+func (h *recordingHooks) OnNodeUp(host string) { h.record("up " + host) }
+func (h *recordingHooks) OnDisconnected()      { h.record("disconnected") }
+func (h *recordingHooks) OnReconnected()       { h.record("reconnected") }
+
+func TestHttpTransport_Hooks(t *testing.T) {
+	const (
+		host      = "http://127.0.0.1:8080"
+		useragent = "test-client"
+	)
+
+	var (
+		listener = fasthttputil.NewInmemoryListener()
+		server   = fasthttp.Server{}
+		hooks    = &recordingHooks{}
+	)
+
+	server.Handler = func(ctx *fasthttp.RequestCtx) {
+		if string(ctx.Path()) == "/_bulk" {
+			ctx.SetStatusCode(500)
+
+			return
+		}
+
+		ctx.SetStatusCode(200)
+	}
+
+	go server.Serve(listener)
+
+	client := NewNodeClient(host, useragent)
+	client.client.Dial = func(addr string) (conn net.Conn, err error) { return listener.Dial() }
+
+	transport := &httpTransport{
+		clientsPool:    &SinglePool{client: client},
+		connStatus:     isLive,
+		requestTimeout: time.Second,
+		successCodes:   map[int]bool{200: true},
+		hooks:          hooks,
+		deadSignal:     make(internal.Signal, 1),
+		liveSignal:     make(internal.Signal, 1),
+	}
+
+	transport.version.Store(Version{Distribution: Elasticsearch, Major: 7})
+
+	assert.Equal(t, ErrNoAvailableClients, transport.SendBulk([]byte("bulk")))
+
+	go transport.pingDeadNodes()
+
+	select {
+	case <-transport.IsReconnected():
+	case <-time.After(time.Second):
+		t.Fatal("not reconnected")
+	}
+
+	hooks.mu.Lock()
+	assert.Equal(t, []string{
+		"down " + host + ": unexpected status code 500",
+		"disconnected",
+		"up " + host,
+		"reconnected",
+	}, hooks.events)
+	hooks.mu.Unlock()
+
+	listener.Close()
+	server.Shutdown()
+}
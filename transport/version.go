@@ -0,0 +1,74 @@
+package transport
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+const (
+	Elasticsearch = "elasticsearch"
+	OpenSearch    = "opensearch"
+)
+
+// Version of the server, zero value means unknown version.
+type Version struct {
+	// Distribution is Elasticsearch if empty.
+	Distribution string
+	Major        int
+}
+
+// IsUnknown reports whether version isn't detected yet.
+func (v Version) IsUnknown() bool {
+	return v.Major == 0
+}
+
+// DocType returns mapping type of documents required by the server.
+// Types are required by Elasticsearch 6.x, deprecated in 7.x and rejected in 8.x,
+// so it's empty for unknown versions and OpenSearch.
+func (v Version) DocType() string {
+	if v.Distribution != OpenSearch && v.Major > 0 && v.Major < 7 {
+		return "doc"
+	}
+
+	return ""
+}
+
+// CompatibleWith returns major version of REST API requested by the compatibility headers.
+// Zero means headers are not required.
+func (v Version) CompatibleWith() int {
+	if v.Distribution != OpenSearch && v.Major >= 8 {
+		return 7
+	}
+
+	return 0
+}
+
+// ParseVersion parses response body of the info request.
+// Full documentation at https://www.elastic.co/guide/en/elasticsearch/reference/current/rest-api-root.html
+func ParseVersion(body []byte) (v Version, ok bool) {
+	var info struct {
+		Version struct {
+			Number       string `json:"number"`
+			Distribution string `json:"distribution"`
+		} `json:"version"`
+	}
+
+	if err := json.Unmarshal(body, &info); err != nil {
+		return v, false
+	}
+
+	major, err := strconv.Atoi(strings.SplitN(info.Version.Number, ".", 2)[0])
+	if err != nil || major <= 0 {
+		return v, false
+	}
+
+	v.Major = major
+	v.Distribution = info.Version.Distribution
+
+	if v.Distribution == "" {
+		v.Distribution = Elasticsearch
+	}
+
+	return v, true
+}
@@ -0,0 +1,70 @@
+package transport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected Version
+		ok       bool
+	}{
+		{
+			name:     "Elasticsearch",
+			body:     `{"name":"node","version":{"number":"8.11.1","build_flavor":"default"}}`,
+			expected: Version{Distribution: Elasticsearch, Major: 8},
+			ok:       true,
+		},
+		{
+			name:     "OpenSearch",
+			body:     `{"version":{"distribution":"opensearch","number":"2.11.0"}}`,
+			expected: Version{Distribution: OpenSearch, Major: 2},
+			ok:       true,
+		},
+		{
+			name: "NoVersion",
+			body: `{"name":"node"}`,
+			ok:   false,
+		},
+		{
+			name: "InvalidBody",
+			body: ``,
+			ok:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, ok := ParseVersion([]byte(tt.body))
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, v)
+		})
+	}
+}
+
+func TestVersion(t *testing.T) {
+	tests := []struct {
+		name           string
+		version        Version
+		unknown        bool
+		docType        string
+		compatibleWith int
+	}{
+		{name: "Unknown", version: Version{}, unknown: true},
+		{name: "Elasticsearch6", version: Version{Distribution: Elasticsearch, Major: 6}, docType: "doc"},
+		{name: "Elasticsearch7", version: Version{Distribution: Elasticsearch, Major: 7}},
+		{name: "Elasticsearch8", version: Version{Distribution: Elasticsearch, Major: 8}, compatibleWith: 7},
+		{name: "OpenSearch", version: Version{Distribution: OpenSearch, Major: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.unknown, tt.version.IsUnknown())
+			assert.Equal(t, tt.docType, tt.version.DocType())
+			assert.Equal(t, tt.compatibleWith, tt.version.CompatibleWith())
+		})
+	}
+}
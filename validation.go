@@ -0,0 +1,100 @@
+package elw
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/gadavy/elw/batch"
+	"github.com/gadavy/elw/transport"
+)
+
+// InvalidDocumentPolicy defines what to do with documents which are not valid JSON.
+// Elasticsearch refuses the whole bulk request with such a document.
+type InvalidDocumentPolicy int
+
+const (
+	// InvalidDocumentKeep writes documents without validation.
+	InvalidDocumentKeep InvalidDocumentPolicy = iota
+	// InvalidDocumentWrap writes invalid document as a message field of a new document.
+	InvalidDocumentWrap
+	// InvalidDocumentReject passes invalid document to OnReject handler
+	// and the dead-letter index instead of the batch.
+	InvalidDocumentReject
+)
+
+const (
+	messageField       = "message"
+	invalidDocumentErr = "invalid_json"
+)
+
+var (
+	// ErrInvalidIndex is returned for index names refused by Elasticsearch: with uppercase letters,
+	// any of \ / * ? " < > | , # : and space, starting with - _ or +, . and .. or longer than 255 bytes.
+	ErrInvalidIndex = errors.New("invalid index name")
+	// ErrInvalidID is returned for document IDs longer than 512 bytes.
+	ErrInvalidID = errors.New("document id is longer than 512 bytes")
+)
+
+const maxDocumentIDSize = 512
+
+// validateIndexName returns ErrInvalidIndex if Elasticsearch refuses the index name.
+func validateIndexName(index string) error {
+	if index == "" || index == "." || index == ".." || len(index) > maxIndexNameSize {
+		return ErrInvalidIndex
+	}
+
+	if strings.ContainsAny(index, invalidIndexChars) || strings.ContainsAny(index[:1], invalidIndexPrefixes) {
+		return ErrInvalidIndex
+	}
+
+	if strings.ToLower(index) != index {
+		return ErrInvalidIndex
+	}
+
+	return nil
+}
+
+// validateDocumentID returns ErrInvalidID if Elasticsearch refuses the document ID.
+func validateDocumentID(id string) error {
+	if len(id) > maxDocumentIDSize {
+		return ErrInvalidID
+	}
+
+	return nil
+}
+
+// validateDocument returns the document to write according to the invalid document policy,
+// ok is false if the document must be skipped.
+func (w *ElasticWriter) validateDocument(doc []byte) (res []byte, ok bool) {
+	if w.invalidDocumentPolicy == InvalidDocumentKeep || json.Valid(doc) {
+		return doc, true
+	}
+
+	if w.invalidDocumentPolicy == InvalidDocumentWrap {
+		data, err := json.Marshal(map[string]string{messageField: string(doc)})
+		if err != nil {
+			return nil, false
+		}
+
+		return data, true
+	}
+
+	failed := transport.FailedItem{
+		Action: batch.ActionIndex,
+		BulkItem: transport.BulkItem{
+			Error: &transport.BulkItemReason{
+				Type:   invalidDocumentErr,
+				Reason: json.Unmarshal(doc, new(interface{})).Error(),
+			},
+		},
+	}
+
+	w.reject(failed, doc)
+
+	if w.deadLetterIndex != "" {
+		w.appendDeadLetter(*w.batch, failed, doc)
+	}
+
+	return nil, false
+}
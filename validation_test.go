@@ -0,0 +1,98 @@
+package elw
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gadavy/elw/test"
+)
+
+func TestElasticWriter_validateDocument(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     InvalidDocumentPolicy
+		deadLetter string
+		doc        string
+		expected   string
+		ok         bool
+		rejected   bool
+		batch      bool
+	}{
+		{name: "Keep", policy: InvalidDocumentKeep, doc: `plain "text"`, expected: `plain "text"`, ok: true},
+		{name: "Valid", policy: InvalidDocumentReject, doc: `{"a":1}`, expected: `{"a":1}`, ok: true},
+		{name: "Wrap", policy: InvalidDocumentWrap, doc: `plain "text"`, expected: `{"message":"plain \"text\""}`, ok: true},
+		{name: "Reject", policy: InvalidDocumentReject, doc: `{"a":}`, rejected: true},
+		{name: "RejectToDeadLetter", policy: InvalidDocumentReject, deadLetter: "dead", doc: `{"a":}`, rejected: true, batch: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rejections []Rejection
+
+			writer := &ElasticWriter{
+				transport:             &test.MockTransport{},
+				timeFormat:            DefaultTimeFormat,
+				deadLetterIndex:       tt.deadLetter,
+				invalidDocumentPolicy: tt.policy,
+				onReject: func(r Rejection) {
+					rejections = append(rejections, r)
+				},
+			}
+			writer.batch = writer.acquireBatch()
+
+			res, ok := writer.validateDocument([]byte(tt.doc))
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, string(res))
+			assert.Equal(t, tt.batch, (*writer.batch).Len() > 0)
+
+			if assert.Equal(t, tt.rejected, len(rejections) == 1) && tt.rejected {
+				assert.Equal(t, invalidDocumentErr, rejections[0].ErrorType)
+				assert.Equal(t, tt.doc, string(rejections[0].Document))
+				assert.NotEmpty(t, rejections[0].Reason)
+			}
+		})
+	}
+}
+
+func TestValidateIndexName(t *testing.T) {
+	tests := []struct {
+		index string
+		valid bool
+	}{
+		{index: "logs-app-2020.01.02", valid: true},
+		{index: ".ds-logs", valid: true},
+		{index: "", valid: false},
+		{index: ".", valid: false},
+		{index: "..", valid: false},
+		{index: "Logs", valid: false},
+		{index: "logs app", valid: false},
+		{index: `users"x`, valid: false},
+		{index: `users\x`, valid: false},
+		{index: "logs:app", valid: false},
+		{index: "logs,app", valid: false},
+		{index: "_logs", valid: false},
+		{index: "-logs", valid: false},
+		{index: "+logs", valid: false},
+		{index: strings.Repeat("x", 255), valid: true},
+		{index: strings.Repeat("x", 256), valid: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.index, func(t *testing.T) {
+			err := validateIndexName(tt.index)
+
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, ErrInvalidIndex, err)
+			}
+		})
+	}
+}
+
+func TestValidateDocumentID(t *testing.T) {
+	assert.NoError(t, validateDocumentID(`a"b\c`))
+	assert.NoError(t, validateDocumentID(strings.Repeat("x", 512)))
+	assert.Equal(t, ErrInvalidID, validateDocumentID(strings.Repeat("x", 513)))
+}
@@ -1,7 +1,9 @@
 package elw
 
 import (
+	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gadavy/elw/batch"
@@ -13,7 +15,20 @@ import (
 func NewElasticWriter(cfg Config) (*ElasticWriter, error) {
 	cfg.validate()
 
-	tr, err := transport.New(cfg.getTransportConfig())
+	staticFields, staticFieldsRaw, err := encodeStaticFields(cfg.StaticFields)
+	if err != nil {
+		return nil, err
+	}
+
+	logLimiter := internal.NewRateLimiter(cfg.LogRateLimit)
+
+	trCfg := cfg.getTransportConfig()
+
+	if cfg.Logger != nil {
+		trCfg.Hooks = &nodeLogger{hooks: trCfg.Hooks, logger: cfg.Logger, limiter: logLimiter}
+	}
+
+	tr, err := transport.New(trCfg)
 	if err != nil {
 		return nil, err
 	}
@@ -29,86 +44,270 @@ func NewElasticWriter(cfg Config) (*ElasticWriter, error) {
 		timeFormat:   cfg.TimeFormat,
 		rotatePeriod: cfg.RotatePeriod,
 		dropStorage:  cfg.DropStorage,
-
-		transport: tr,
-		storage:   st,
+		onReject:     cfg.OnReject,
+		hooks:        cfg.Hooks,
+
+		invalidDocumentPolicy: cfg.InvalidDocumentPolicy,
+		maxDocumentSize:       cfg.MaxDocumentSize,
+		oversizePolicy:        cfg.OversizePolicy,
+		truncateField:         cfg.TruncateField,
+
+		deadLetterIndex: cfg.DeadLetterIndex,
+		timestampFields: cfg.TimestampFields,
+		location:        cfg.TimeZone,
+		dataStream:      []byte(cfg.DataStream),
+
+		idStrategy: cfg.DocumentIDs,
+		instanceID: cfg.InstanceID,
+		encoder:    cfg.Encoder,
+
+		pipeline:      cfg.Pipeline,
+		routing:       cfg.Routing,
+		requireAlias:  cfg.RequireAlias,
+		pipelineField: cfg.PipelineField,
+		routingField:  cfg.RoutingField,
+
+		staticFields:          staticFields,
+		staticFieldsRaw:       staticFieldsRaw,
+		overwriteStaticFields: cfg.OverwriteStaticFields,
+
+		transport:  tr,
+		storage:    st,
+		logger:     cfg.Logger,
+		logLimiter: logLimiter,
 
 		done: make(internal.Signal, 1),
+		mu:   internal.NewMutex(),
 		wg:   new(sync.WaitGroup),
+
+		queue:          make(chan queuedBatch, cfg.QueueSize),
+		overflowPolicy: cfg.OverflowPolicy,
+		stop:           make(chan struct{}),
+	}
+
+	if ew.encoder == nil {
+		ew.encoder = JSONEncoder{}
+	}
+
+	if ew.instanceID == "" {
+		ew.instanceID = newInstanceID()
+	}
+
+	if cfg.IndexTemplate != "" {
+		ew.indexTemplate = parseIndexTemplate(cfg.IndexTemplate)
+	}
+
+	if cfg.DataStream != "" && cfg.CreateDataStreamTemplate {
+		ew.dataStreamTemplate = dataStreamTemplate(cfg.DataStream)
+		ew.putDataStreamTemplate()
 	}
 
 	ew.batch = ew.acquireBatch()
 	ew.timer = time.NewTimer(ew.rotatePeriod)
 
+	for i := 0; i < cfg.Workers; i++ {
+		go ew.sender()
+	}
+
 	go ew.worker()
 
 	return ew, nil
 }
 
 type ElasticWriter struct {
-	noCopy noCopy // nolint:unused,structcheck
+	noCopy   noCopy // nolint:unused,structcheck
+	counters queueCounters
+	stats    writerCounters
 
-	transport transport.Transport
-	storage   storage.Storage
-	logger    Logger
+	transport  transport.Transport
+	storage    storage.Storage
+	logger     Logger
+	logLimiter *internal.RateLimiter
 
 	batchSize    int
 	rotatePeriod time.Duration
 	indexName    string
 	timeFormat   string
 	dropStorage  bool
+	onReject     RejectHandler
+	hooks        Hooks
+
+	invalidDocumentPolicy InvalidDocumentPolicy
+	maxDocumentSize       int
+	oversizePolicy        OversizePolicy
+	truncateField         string
+
+	deadLetterIndex string
+	indexTemplate   indexTemplate
+	indexBuf        []byte
+	docBuf          []byte
+	compactBuf      []byte
+	timeBuf         []byte
+	timestampFields []string
+	location        *time.Location
+	dataStream      []byte
+
+	// dataStreamTemplate is put by the worker until the cluster accepts or refuses it,
+	// next attempt is not made before templateRetry.
+	dataStreamTemplate []byte
+	templateRetry      time.Time
+	templateBackoff    time.Duration
+
+	idStrategy IDStrategy
+	instanceID string
+	idSequence uint64
+	idBuf      []byte
+
+	encoder    Encoder
+	encodePool sync.Pool
+
+	pipeline      string
+	routing       string
+	requireAlias  bool
+	pipelineField string
+	routingField  string
+
+	staticFields          []staticField
+	staticFieldsRaw       []byte
+	overwriteStaticFields bool
+	fieldsBuf             []byte
+	enrichBuf             []byte
 
 	once internal.Once
 	done internal.Signal
 
-	mu    sync.Mutex
+	mu    internal.Mutex
 	batch **batch.Batch
+	group *deliveryGroup
 	timer *time.Timer
 
+	// actions of the bulk indexer in the current batch.
+	actions []BulkIndexerItem
+
 	wg *sync.WaitGroup
 
+	queue          chan queuedBatch
+	overflowPolicy OverflowPolicy
+	stop           chan struct{}
+	stopOnce       sync.Once
+
 	batchPool sync.Pool
 }
 
+// Write appends documents to the current batch. Every line of p is a separate document,
+// except JSON objects and arrays spanning several lines, which are compacted.
+// Blank lines are skipped.
 func (w *ElasticWriter) Write(p []byte) (n int, err error) {
-	w.mu.Lock()
+	return w.WriteContext(context.Background(), p)
+}
 
-	if (*w.batch).Len()+len(p) > w.batchSize {
-		w.rotateBatch()
+// WriteContext is like Write, but waits for the writer and the queue of batches
+// only until the context is done. Full batch that can't be queued in time is put to the storage.
+func (w *ElasticWriter) WriteContext(ctx context.Context, p []byte) (n int, err error) {
+	if err = w.mu.LockContext(ctx); err != nil {
+		return 0, err
 	}
 
-	(*w.batch).AppendMeta(w.indexName, w.timeFormat)
-	(*w.batch).AppendBytes(p)
+	var ok bool
+
+	for doc, rest := internal.NextDocument(p); doc != nil; doc, rest = internal.NextDocument(rest) {
+		if doc, ok = w.validateDocument(w.compactDocument(doc)); !ok {
+			continue
+		}
+
+		w.addDocument(ctx, doc, nil)
+	}
 
 	w.mu.Unlock()
 
 	return len(p), nil
 }
 
+// addDocument appends the document to the current batch, rotating full batches.
+// Meta overrides settings of the document if not nil.
+func (w *ElasticWriter) addDocument(ctx context.Context, doc []byte, meta *DocumentMeta) {
+	var ok, alone bool
+
+	if w.maxDocumentSize > 0 && len(doc) > w.maxDocumentSize {
+		if doc, ok = w.oversizeDocument(doc); !ok {
+			return
+		}
+
+		// document that is still oversized is sent as a single-document request.
+		alone = len(doc) > w.maxDocumentSize
+	}
+
+	doc = w.addStaticFields(doc)
+
+	if alone || (*w.batch).Len()+len(doc) > w.batchSize {
+		w.rotateBatch(ctx)
+	}
+
+	w.appendDocument(doc, meta)
+
+	atomic.AddUint64(&w.stats.documents, 1)
+	atomic.AddUint64(&w.stats.bytes, uint64(len(doc)))
+
+	// document larger than the batch is sent alone.
+	if alone || (*w.batch).Len() >= w.batchSize {
+		w.rotateBatch(ctx)
+	}
+}
+
+// Sync sends the current batch and waits until all batches rotated so far
+// are delivered to Elasticsearch or put to the storage. SyncError is returned
+// for batches for which neither happened.
 func (w *ElasticWriter) Sync() error {
-	w.mu.Lock()
+	return w.SyncContext(context.Background())
+}
 
-	w.rotateBatch()
+// SyncContext is like Sync, but waits only until the context is done.
+// Queued batches that were not sent in time are put to the storage
+// and DeliveryError is returned.
+func (w *ElasticWriter) SyncContext(ctx context.Context) error {
+	if err := w.mu.LockContext(ctx); err != nil {
+		return err
+	}
+
+	w.rotateBatch(ctx)
+
+	g := w.group
+	w.group = g.seal()
 
 	w.mu.Unlock()
 
-	return nil
+	return w.waitDelivery(ctx, g)
 }
 
 func (w *ElasticWriter) Close() error {
+	return w.CloseContext(context.Background())
+}
+
+// CloseContext sends the current batch and the storage, waiting for delivery
+// until the context is done. Queued batches that were not sent in time are put
+// to the storage, which is kept even with DropStorage, and DeliveryError is returned.
+// If a write holds the writer until the context is done, e.g. waiting for the full queue,
+// the current batch is left to that write.
+func (w *ElasticWriter) CloseContext(ctx context.Context) error {
 	w.done.Send()
 
-	w.mu.Lock()
+	if err := w.mu.LockContext(ctx); err != nil {
+		return w.undelivered(err)
+	}
 
-	w.rotateBatch()
+	w.rotateBatch(ctx)
+	w.replayStorage()
 
-	w.wg.Add(1)
-	w.once.DoWG(w.wg, w.releaseStorage)
+	err := w.waitAll(ctx)
 
-	w.wg.Wait()
+	w.stopSenders()
 
 	w.mu.Unlock()
 
+	if err != nil {
+		return err
+	}
+
 	if w.dropStorage {
 		return w.storage.Drop()
 	}
@@ -116,13 +315,18 @@ func (w *ElasticWriter) Close() error {
 	return nil
 }
 
-func (w *ElasticWriter) rotateBatch() {
+func (w *ElasticWriter) rotateBatch(ctx context.Context) {
+	if w.group == nil {
+		w.group = firstDeliveryGroup()
+	}
+
 	if (*w.batch).Len() > 0 {
-		w.wg.Add(1)
+		atomic.AddUint64(&w.stats.actionsFlushed, uint64(len(w.actions)))
 
-		go w.releaseBatch(*w.batch)
+		w.enqueueBatch(ctx, *w.batch, w.actions, w.group)
 
 		w.batch = w.acquireBatch()
+		w.actions = nil
 	}
 
 	w.timer.Reset(w.rotatePeriod)
@@ -137,66 +341,35 @@ func (w *ElasticWriter) acquireBatch() **batch.Batch {
 	return &b
 }
 
-func (w *ElasticWriter) releaseBatch(b *batch.Batch) {
-	defer w.wg.Done()
-	defer w.batchPool.Put(b)
-	defer b.Reset()
-
-	var err error
-
-	switch w.transport.IsConnected() {
-	case true:
-		if err = w.transport.SendBulk(b.Bytes()); err == nil {
-			return
-		}
-
-		fallthrough
-	case false:
-		if err = w.storage.Put(b.Bytes()); err == nil {
-			return
-		}
-
-		if w.logger != nil {
-			w.logger.Printf("release batch = %s failed: %v", b.String(), err)
-		}
-	}
-}
-
-func (w *ElasticWriter) releaseStorage() {
-	var (
-		buf []byte
-		err error
-	)
-
-	for w.transport.IsConnected() && w.storage.IsUsed() {
-		if buf, err = w.storage.Pop(); err != nil {
-			continue
-		}
-
-		if err = w.transport.SendBulk(buf); err == nil {
-			continue
-		}
-
-		if err = w.storage.Put(buf); err == nil {
-			continue
-		}
+// replayStorage starts sending of the storage in background.
+// The writer must be locked, so Close waits for it.
+func (w *ElasticWriter) replayStorage() {
+	w.wg.Add(1)
 
-		if w.logger != nil {
-			w.logger.Printf("release batch = %s failed: %v", buf, err)
-		}
-	}
+	go w.once.DoWG(w.wg, w.releaseStorage)
 }
 
 func (w *ElasticWriter) worker() {
 	for {
 		select {
 		case <-w.transport.IsReconnected():
-			w.wg.Add(1)
+			w.templateRetry = time.Time{}
+			w.putDataStreamTemplate()
 
-			go w.once.DoWG(w.wg, w.releaseStorage)
+			w.mu.Lock()
+			w.replayStorage()
+			w.mu.Unlock()
 		case <-w.timer.C:
+			w.putDataStreamTemplate()
+
 			w.mu.Lock()
-			w.rotateBatch()
+			w.rotateBatch(context.Background())
+
+			// retry documents postponed by the overloaded cluster.
+			if w.storage.IsUsed() && w.transport.IsConnected() {
+				w.replayStorage()
+			}
+
 			w.mu.Unlock()
 		case <-w.done:
 			return
@@ -1,6 +1,8 @@
 package elw
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"sync"
 	"sync/atomic"
@@ -8,10 +10,12 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 
 	"github.com/gadavy/elw/batch"
 	"github.com/gadavy/elw/internal"
 	"github.com/gadavy/elw/test"
+	"github.com/gadavy/elw/transport"
 )
 
 func TestElasticWriter_releaseBatch(t *testing.T) {
@@ -67,8 +71,10 @@ func TestElasticWriter_releaseBatch(t *testing.T) {
 			storagePutOut: []interface{}{
 				(error)(nil),
 			},
-			logger:   &test.MockLogger{},
-			loggerIn: nil,
+			logger: &test.MockLogger{},
+			loggerIn: []interface{}{
+				"batch of 8 bytes put to storage: transport error",
+			},
 		},
 		{
 			name:      "IsConnectedPutError",
@@ -143,7 +149,9 @@ func TestElasticWriter_releaseBatch(t *testing.T) {
 
 			tt.storage.On("Put", tt.storagePutIn...).Return(tt.storagePutOut...)
 
-			tt.logger.On("Printf", tt.loggerIn...)
+			for _, msg := range tt.loggerIn {
+				tt.logger.On("Printf", msg)
+			}
 
 			b := batch.NewBatch(len(tt.input))
 			b.AppendBytes(tt.input)
@@ -157,11 +165,105 @@ func TestElasticWriter_releaseBatch(t *testing.T) {
 
 			writer.wg.Add(1)
 
-			writer.releaseBatch(b)
+			writer.releaseBatch(b, nil)
 		})
 	}
 }
 
+func TestElasticWriter_retryFailed(t *testing.T) {
+	body := []byte("{\"index\":{}}\n{\"a\":1}\n{\"index\":{}}\n{\"b\":2}\n{\"index\":{}}\n{\"c\":3}\n")
+
+	bulkErr := &transport.BulkError{Items: []transport.FailedItem{
+		{
+			Position: 0,
+			Action:   "index",
+			BulkItem: transport.BulkItem{
+				Index:  "test",
+				Status: 429,
+				Error:  &transport.BulkItemReason{Type: "es_rejected_execution_exception"},
+			},
+		},
+		{
+			Position: 2,
+			Action:   "index",
+			BulkItem: transport.BulkItem{
+				Index:  "test",
+				Status: 400,
+				Error:  &transport.BulkItemReason{Type: "mapper_parsing_exception", Reason: "failed to parse"},
+			},
+		},
+	}}
+
+	storage := &test.MockStorage{}
+	storage.On("Put", []byte("{\"index\":{}}\n{\"a\":1}\n")).Return((error)(nil))
+
+	var rejected []Rejection
+
+	writer := ElasticWriter{
+		storage: storage,
+		onReject: func(r Rejection) {
+			r.Document = append([]byte(nil), r.Document...)
+			rejected = append(rejected, r)
+		},
+	}
+
+	assert.True(t, writer.retryFailed(body, bulkErr, nil))
+	assert.Equal(t, []Rejection{{
+		Index:     "test",
+		Status:    400,
+		ErrorType: "mapper_parsing_exception",
+		Reason:    "failed to parse",
+		Document:  []byte("{\"c\":3}"),
+	}}, rejected)
+
+	storage.AssertExpectations(t)
+}
+
+func TestElasticWriter_sendDeadLetters(t *testing.T) {
+	body := []byte("{\"index\":{}}\n{\"a\":1}\n")
+
+	bulkErr := &transport.BulkError{Items: []transport.FailedItem{
+		{
+			Position: 0,
+			Action:   "index",
+			BulkItem: transport.BulkItem{
+				Index:  "test-2020.01.01",
+				Status: 400,
+				Error:  &transport.BulkItemReason{Type: "mapper_parsing_exception", Reason: "failed to parse"},
+			},
+		},
+	}}
+
+	isDeadLetter := func(body []byte) bool {
+		return bytes.Contains(body, []byte("{\"index\":{\"_type\":\"doc\",\"_index\":\"dead-")) &&
+			bytes.Contains(body, []byte("\"index\":\"test-2020.01.01\"")) &&
+			bytes.Contains(body, []byte("\"error_type\":\"mapper_parsing_exception\"")) &&
+			bytes.Contains(body, []byte("\"document\":\"{\\\"a\\\":1}\""))
+	}
+
+	tr := &test.MockTransport{ServerVersion: transport.Version{Major: 6}}
+	tr.On("SendBulk", mock.MatchedBy(isDeadLetter)).Return((error)(nil))
+
+	writer := ElasticWriter{
+		transport:       tr,
+		storage:         &test.MockStorage{},
+		timeFormat:      DefaultTimeFormat,
+		deadLetterIndex: "dead",
+	}
+
+	assert.False(t, writer.retryFailed(body, bulkErr, nil))
+
+	tr.AssertExpectations(t)
+
+	// rejected dead letters are not wrapped again.
+	assert.False(t, writer.isDeadLetterEnabled("dead-2020.01.01"))
+
+	// indices sharing the prefix are not dead-letter ones.
+	assert.True(t, writer.isDeadLetterEnabled("dead"))
+	assert.True(t, writer.isDeadLetterEnabled("dead-app-2020.01.01"))
+	assert.True(t, writer.isDeadLetterEnabled("deadline-2020.01.01"))
+}
+
 func TestElasticWriter_AcquireAndRotateBatch(t *testing.T) {
 	writer := ElasticWriter{
 		transport: new(test.StubTransport),
@@ -180,7 +282,7 @@ func TestElasticWriter_AcquireAndRotateBatch(t *testing.T) {
 
 	(*expected).AppendBytes([]byte("1"))
 
-	writer.rotateBatch()
+	writer.rotateBatch(context.Background())
 
 	assert.NotEqual(t, []byte("1"), (*writer.batch).Bytes())
 }
@@ -220,7 +322,9 @@ func TestElasticWriter_releaseStorage(t *testing.T) {
 			storagePutIn:  nil,
 			storagePutOut: nil,
 			logger:        &test.MockLogger{},
-			loggerIn:      nil,
+			loggerIn: []interface{}{
+				"pop batch from storage failed: storage error",
+			},
 		},
 		{
 			name:      "SendBulkPass",
@@ -247,7 +351,9 @@ func TestElasticWriter_releaseStorage(t *testing.T) {
 			storagePutIn:  nil,
 			storagePutOut: nil,
 			logger:        &test.MockLogger{},
-			loggerIn:      nil,
+			loggerIn: []interface{}{
+				"replayed 1 batches from storage",
+			},
 		},
 		{
 			name:      "PutPass",
@@ -277,8 +383,10 @@ func TestElasticWriter_releaseStorage(t *testing.T) {
 			storagePutOut: []interface{}{
 				(error)(nil),
 			},
-			logger:   &test.MockLogger{},
-			loggerIn: nil,
+			logger: &test.MockLogger{},
+			loggerIn: []interface{}{
+				"replay batch of 7 bytes failed, put back to storage: transport error",
+			},
 		},
 		{
 			name:      "PutError",
@@ -310,6 +418,7 @@ func TestElasticWriter_releaseStorage(t *testing.T) {
 			},
 			logger: &test.MockLogger{},
 			loggerIn: []interface{}{
+				"replay batch of 7 bytes failed, put back to storage: transport error",
 				"release batch = message failed: storage error",
 			},
 		},
@@ -323,7 +432,9 @@ func TestElasticWriter_releaseStorage(t *testing.T) {
 			tt.storage.On("Pop").Return(tt.storagePopOut...)
 			tt.storage.On("Put", tt.storagePutIn...).Return(tt.storagePutOut...)
 
-			tt.logger.On("Printf", tt.loggerIn...)
+			for _, msg := range tt.loggerIn {
+				tt.logger.On("Printf", msg)
+			}
 
 			writer := ElasticWriter{
 				transport: tt.transport,
@@ -356,7 +467,7 @@ func TestElasticWriter_Write(t *testing.T) {
 				true,
 			},
 			transportSendBulkIn: []interface{}{
-				[]byte("test message\n"),
+				[]byte("{\"index\":{\"_index\":\"-\"}}\ntest message\n"),
 			},
 			transportSendBulkOut: []interface{}{
 				(error)(nil),
@@ -371,6 +482,7 @@ func TestElasticWriter_Write(t *testing.T) {
 			writer := ElasticWriter{
 				batchSize: tt.batchSize,
 				transport: tt.transport,
+				mu:        internal.NewMutex(),
 				wg:        new(sync.WaitGroup),
 
 				rotatePeriod: time.Second,
@@ -389,7 +501,7 @@ func TestElasticWriter_Write(t *testing.T) {
 
 func TestElasticWriter_Sync(t *testing.T) {
 	message := []byte("test message")
-	expected := []byte("{\"index\":{\"_type\":\"doc\",\"_index\":\"-\"}}\ntest message\n")
+	expected := []byte("{\"index\":{\"_index\":\"-\"}}\ntest message\n")
 
 	transport := &test.MockTransport{}
 	transport.On("IsConnected").Return([]interface{}{true}...)
@@ -398,6 +510,7 @@ func TestElasticWriter_Sync(t *testing.T) {
 	writer := ElasticWriter{
 		batchSize: 100,
 		transport: transport,
+		mu:        internal.NewMutex(),
 		wg:        new(sync.WaitGroup),
 
 		rotatePeriod: time.Second,
@@ -420,6 +533,7 @@ func TestElasticWriter_Close(t *testing.T) {
 		batchSize: 100,
 		transport: &test.StubTransport{Ch: reconnectCh},
 		storage:   &test.StubStorage{},
+		mu:        internal.NewMutex(),
 		wg:        new(sync.WaitGroup),
 
 		rotatePeriod: time.Second,
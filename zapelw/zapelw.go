@@ -0,0 +1,194 @@
+// Package zapelw writes zap logs to Elasticsearch via elw.ElasticWriter.
+//
+// Entries are encoded as ECS-style JSON documents: @timestamp, log.level, message,
+// log.logger, log.origin.* and error.* fields. Sync of the logger waits for delivery
+// of the written entries, so call it before exit:
+//
+//	writer, err := elw.NewElasticWriter(elw.Config{TimestampFields: []string{"@timestamp"}})
+//	core, err := zapelw.NewCore(zapelw.Config{Writer: writer})
+//	logger := zap.New(core, zap.AddCaller())
+//	defer logger.Sync()
+package zapelw
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/gadavy/elw"
+)
+
+// errorKey is a key of zap.Error fields, which are mapped to error.message and error.type.
+const errorKey = "error"
+
+// ErrNoWriter is returned by NewCore if Config.Writer is nil.
+var ErrNoWriter = errors.New("zapelw: writer is required")
+
+// Writer receives encoded entries, see elw.SyncWriter.
+type Writer = elw.SyncWriter
+
+type Config struct {
+	// Writer receives entries of all levels except LevelWriters ones.
+	Writer       Writer
+	LevelWriters map[zapcore.Level]Writer
+
+	// Level enables entries, zapcore.InfoLevel by default.
+	Level zapcore.LevelEnabler
+	// EncoderConfig is EncoderConfig() by default. Caller is written as
+	// log.origin.* fields if CallerKey is empty.
+	EncoderConfig *zapcore.EncoderConfig
+
+	// SyncTimeout limits waiting for delivery on Sync, unlimited if zero.
+	SyncTimeout time.Duration
+}
+
+// EncoderConfig returns encoder config of ECS-style documents.
+func EncoderConfig() zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
+		TimeKey:        elw.TimestampKey,
+		LevelKey:       elw.LevelKey,
+		NameKey:        elw.LoggerKey,
+		MessageKey:     elw.MessageKey,
+		StacktraceKey:  elw.StacktraceKey,
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+		EncodeDuration: zapcore.NanosDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+		EncodeName:     zapcore.FullNameEncoder,
+	}
+}
+
+// Core is a zapcore.Core writing JSON documents to elw writers.
+type Core struct {
+	zapcore.LevelEnabler
+
+	enc     zapcore.Encoder
+	writers *elw.LevelWriters
+	origin  bool
+}
+
+func NewCore(cfg Config) (*Core, error) {
+	if cfg.Writer == nil {
+		return nil, ErrNoWriter
+	}
+
+	if cfg.Level == nil {
+		cfg.Level = zapcore.InfoLevel
+	}
+
+	if cfg.EncoderConfig == nil {
+		encCfg := EncoderConfig()
+		cfg.EncoderConfig = &encCfg
+	}
+
+	writers := elw.NewLevelWriters(cfg.Writer, cfg.SyncTimeout)
+
+	for level, w := range cfg.LevelWriters {
+		writers.Set(int(level), w)
+	}
+
+	return &Core{
+		LevelEnabler: cfg.Level,
+		enc:          zapcore.NewJSONEncoder(*cfg.EncoderConfig),
+		writers:      writers,
+		origin:       cfg.EncoderConfig.CallerKey == "",
+	}, nil
+}
+
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.enc = c.enc.Clone()
+
+	for _, f := range ecsFields(fields) {
+		f.AddTo(clone.enc)
+	}
+
+	return &clone
+}
+
+func (c *Core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *Core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	fields = ecsFields(fields)
+
+	if c.origin && ent.Caller.Defined {
+		fields = append(fields[:len(fields):len(fields)],
+			zapcore.Field{Key: elw.FileNameKey, Type: zapcore.StringType, String: ent.Caller.TrimmedPath()},
+			zapcore.Field{Key: elw.FileLineKey, Type: zapcore.Int64Type, Integer: int64(ent.Caller.Line)},
+		)
+
+		if ent.Caller.Function != "" {
+			fields = append(fields, zapcore.Field{Key: elw.FunctionKey, Type: zapcore.StringType, String: ent.Caller.Function})
+		}
+	}
+
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.writers.Writer(int(ent.Level)).Write(buf.Bytes())
+	buf.Free()
+
+	if err != nil {
+		return err
+	}
+
+	// entries above error level are followed by panic or exit.
+	if ent.Level > zapcore.ErrorLevel {
+		return c.Sync()
+	}
+
+	return nil
+}
+
+// Sync waits until written entries are delivered to Elasticsearch or put to the storage
+// of every writer and returns the first error.
+func (c *Core) Sync() error {
+	return c.writers.Sync()
+}
+
+// ecsFields replaces zap.Error fields with error.message and error.type.
+func ecsFields(fields []zapcore.Field) []zapcore.Field {
+	var res []zapcore.Field
+
+	for i, f := range fields {
+		if f.Type != zapcore.ErrorType || f.Key != errorKey {
+			if res != nil {
+				res = append(res, f)
+			}
+
+			continue
+		}
+
+		if res == nil {
+			res = make([]zapcore.Field, i, len(fields)+1)
+			copy(res, fields[:i])
+		}
+
+		err, ok := f.Interface.(error)
+		if !ok || err == nil {
+			continue
+		}
+
+		res = append(res,
+			zapcore.Field{Key: elw.ErrorMessageKey, Type: zapcore.StringType, String: err.Error()},
+			zapcore.Field{Key: elw.ErrorTypeKey, Type: zapcore.StringType, String: fmt.Sprintf("%T", err)},
+		)
+	}
+
+	if res == nil {
+		return fields
+	}
+
+	return res
+}
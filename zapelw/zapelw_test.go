@@ -0,0 +1,121 @@
+package zapelw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/gadavy/elw"
+)
+
+var _ Writer = (*elw.ElasticWriter)(nil)
+
+type recordingWriter struct {
+	docs  []map[string]interface{}
+	syncs int
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	var doc map[string]interface{}
+
+	if err := json.Unmarshal(p, &doc); err != nil {
+		return 0, err
+	}
+
+	w.docs = append(w.docs, doc)
+
+	return len(p), nil
+}
+
+func (w *recordingWriter) SyncContext(ctx context.Context) error {
+	w.syncs++
+
+	return nil
+}
+
+func TestNewCore(t *testing.T) {
+	_, err := NewCore(Config{})
+
+	assert.Equal(t, ErrNoWriter, err)
+}
+
+func TestCore_Write(t *testing.T) {
+	writer := &recordingWriter{}
+
+	core, err := NewCore(Config{Writer: writer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := zap.New(core, zap.AddCaller()).Named("app").With(zap.String("service", "api"))
+
+	logger.Debug("skipped")
+	logger.Info("test message", zap.Int("count", 2), zap.Error(os.ErrNotExist))
+
+	if !assert.Len(t, writer.docs, 1) {
+		return
+	}
+
+	doc := writer.docs[0]
+
+	assert.Contains(t, doc, elw.TimestampKey)
+	assert.Equal(t, "info", doc[elw.LevelKey])
+	assert.Equal(t, "app", doc[elw.LoggerKey])
+	assert.Equal(t, "test message", doc[elw.MessageKey])
+	assert.Equal(t, "api", doc["service"])
+	assert.Equal(t, float64(2), doc["count"])
+	assert.Equal(t, os.ErrNotExist.Error(), doc[elw.ErrorMessageKey])
+	assert.Equal(t, "*errors.errorString", doc[elw.ErrorTypeKey])
+	assert.NotContains(t, doc, "error")
+	assert.Contains(t, doc[elw.FileNameKey], "zapelw/zapelw_test.go")
+	assert.Contains(t, doc, elw.FileLineKey)
+	assert.Contains(t, doc[elw.FunctionKey], "TestCore_Write")
+}
+
+func TestCore_LevelWriters(t *testing.T) {
+	writer, errWriter := &recordingWriter{}, &recordingWriter{}
+
+	core, err := NewCore(Config{
+		Writer:       writer,
+		LevelWriters: map[zapcore.Level]Writer{zapcore.ErrorLevel: errWriter, zapcore.DPanicLevel: errWriter},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := zap.New(core)
+
+	logger.Info("info")
+	logger.Error("error", zap.Error(errors.New("failed")))
+
+	assert.Len(t, writer.docs, 1)
+	assert.Len(t, errWriter.docs, 1)
+
+	assert.NoError(t, logger.Sync())
+	assert.Equal(t, 1, writer.syncs)
+	assert.Equal(t, 1, errWriter.syncs)
+
+	// entries above error level are synced before panic.
+	assert.NoError(t, core.Write(zapcore.Entry{Level: zapcore.PanicLevel}, nil))
+	assert.Equal(t, 2, writer.syncs)
+}
+
+func TestEcsFields(t *testing.T) {
+	fields := []zapcore.Field{zap.String("a", "b"), zap.Error(errors.New("x")), zap.NamedError("cause", errors.New("y"))}
+
+	expected := []zapcore.Field{
+		fields[0],
+		zap.String(elw.ErrorMessageKey, "x"),
+		zap.String(elw.ErrorTypeKey, "*errors.errorString"),
+		fields[2],
+	}
+
+	assert.Equal(t, expected, ecsFields(fields))
+	assert.Equal(t, fields[:1], ecsFields(fields[:1]))
+}
@@ -0,0 +1,77 @@
+// Package zerologelw is a zerolog.LevelWriter routing events to elw.ElasticWriter by level.
+//
+//	writer, err := elw.NewElasticWriter(elw.Config{TimestampFields: []string{"@timestamp"}})
+//	zerologelw.SetFieldNames()
+//	lw, err := zerologelw.NewLevelWriter(zerologelw.Config{Writer: writer})
+//	logger := zerolog.New(lw).With().Timestamp().Logger()
+package zerologelw
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/gadavy/elw"
+)
+
+// ErrNoWriter is returned by NewLevelWriter if Config.Writer is nil.
+var ErrNoWriter = errors.New("zerologelw: writer is required")
+
+// Writer receives events, see elw.SyncWriter.
+type Writer = elw.SyncWriter
+
+type Config struct {
+	// Writer receives events of all levels except LevelWriters ones.
+	Writer       Writer
+	LevelWriters map[zerolog.Level]Writer
+}
+
+// LevelWriter is a zerolog.LevelWriter, events written without level go to Config.Writer.
+type LevelWriter struct {
+	writer  Writer
+	writers *elw.LevelWriters
+}
+
+func NewLevelWriter(cfg Config) (*LevelWriter, error) {
+	if cfg.Writer == nil {
+		return nil, ErrNoWriter
+	}
+
+	writers := elw.NewLevelWriters(cfg.Writer, 0)
+
+	for level, w := range cfg.LevelWriters {
+		writers.Set(int(level), w)
+	}
+
+	return &LevelWriter{writer: cfg.Writer, writers: writers}, nil
+}
+
+func (w *LevelWriter) Write(p []byte) (n int, err error) {
+	return w.writer.Write(p)
+}
+
+func (w *LevelWriter) WriteLevel(level zerolog.Level, p []byte) (n int, err error) {
+	return w.writers.Writer(int(level)).Write(p)
+}
+
+// SyncContext waits until written events are delivered to Elasticsearch or put to the storage
+// of every writer and returns the first error.
+func (w *LevelWriter) SyncContext(ctx context.Context) error {
+	return w.writers.SyncContext(ctx)
+}
+
+// SetFieldNames sets global zerolog field names and time format to ECS-style ones:
+// @timestamp, log.level, message, error.message, error.stack_trace and log.origin.file.name.
+// Caller is written without line, since log.origin.file.name is a file name only.
+func SetFieldNames() {
+	zerolog.TimestampFieldName = elw.TimestampKey
+	zerolog.LevelFieldName = elw.LevelKey
+	zerolog.MessageFieldName = elw.MessageKey
+	zerolog.ErrorFieldName = elw.ErrorMessageKey
+	zerolog.ErrorStackFieldName = elw.StacktraceKey
+	zerolog.CallerFieldName = elw.FileNameKey
+	zerolog.CallerMarshalFunc = func(file string, _ int) string { return file }
+	zerolog.TimeFieldFormat = time.RFC3339Nano
+}
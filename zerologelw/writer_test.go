@@ -0,0 +1,80 @@
+package zerologelw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gadavy/elw"
+)
+
+var _ Writer = (*elw.ElasticWriter)(nil)
+
+type recordingWriter struct {
+	docs  []map[string]interface{}
+	syncs int
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	var doc map[string]interface{}
+
+	if err := json.Unmarshal(p, &doc); err != nil {
+		return 0, err
+	}
+
+	w.docs = append(w.docs, doc)
+
+	return len(p), nil
+}
+
+func (w *recordingWriter) SyncContext(ctx context.Context) error {
+	w.syncs++
+
+	return nil
+}
+
+func TestLevelWriter(t *testing.T) {
+	SetFieldNames()
+
+	writer, errWriter := &recordingWriter{}, &recordingWriter{}
+
+	lw, err := NewLevelWriter(Config{
+		Writer:       writer,
+		LevelWriters: map[zerolog.Level]Writer{zerolog.ErrorLevel: errWriter, zerolog.FatalLevel: errWriter},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := zerolog.New(lw).With().Timestamp().Logger()
+
+	logger.Info().Str("service", "api").Caller().Msg("info")
+	logger.Error().Err(errors.New("failed")).Msg("error")
+	logger.Log().Msg("no level")
+
+	if assert.Len(t, writer.docs, 2) {
+		assert.Equal(t, "info", writer.docs[0]["log.level"])
+		assert.Equal(t, "info", writer.docs[0]["message"])
+		assert.Equal(t, "api", writer.docs[0]["service"])
+		assert.Contains(t, writer.docs[0], "@timestamp")
+		assert.Regexp(t, `writer_test\.go$`, writer.docs[0]["log.origin.file.name"])
+	}
+
+	if assert.Len(t, errWriter.docs, 1) {
+		assert.Equal(t, "failed", errWriter.docs[0]["error.message"])
+	}
+
+	assert.NoError(t, lw.SyncContext(context.Background()))
+	assert.Equal(t, 1, writer.syncs)
+	assert.Equal(t, 1, errWriter.syncs)
+}
+
+func TestNewLevelWriter_NoWriter(t *testing.T) {
+	_, err := NewLevelWriter(Config{})
+
+	assert.Equal(t, ErrNoWriter, err)
+}